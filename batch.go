@@ -0,0 +1,130 @@
+package swiftcache
+
+import "time"
+
+// GetMany retrieves the values of keys, like Get, and returns those found.
+// Each key is hashed once and the keys are grouped by segment, so a segment
+// is locked once per batch rather than once per key. Unlike Get, it does not
+// load missing keys, but stale items are still refreshed in the background.
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	result := make(map[K]V, len(keys))
+	var hits []K
+	var items []Item[V]
+	for segment, group := range c.groupBySegment(keys) {
+		hits, items = segment.getMany(group, hits[:0], items[:0])
+		for i, key := range hits {
+			if value, _, found := c.revalidate(segment, key, &items[i], nil); found {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+// SetMany sets all key-value pairs of items in the cache with the same ttl,
+// like Set. The keys are grouped by segment, so a segment is locked once per
+// batch rather than once per key. An item that alone weighs more than a
+// segment may hold is skipped, the others are still set, and ErrItemTooLarge
+// is returned.
+func (c *Cache[K, V]) SetMany(items map[K]V, ttl time.Duration) error {
+	keys := make([]K, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	var err error
+	options := c.writeOptions(ttl)
+	for segment, group := range c.groupBySegment(keys) {
+		weights := make([]int64, len(group))
+		for i, key := range group {
+			weights[i] = c.weigh(key, items[key])
+		}
+		if setErr := segment.setMany(group, items, weights, options, c.defaultExpiration); setErr != nil && err == nil {
+			err = setErr
+		}
+		c.reclaim(segment)
+	}
+	return err
+}
+
+// DeleteMany removes keys from the cache, like Delete. The keys are grouped
+// by segment, so a segment is locked once per batch rather than once per key.
+func (c *Cache[K, V]) DeleteMany(keys []K) {
+	for segment, group := range c.groupBySegment(keys) {
+		segment.deleteMany(group)
+	}
+}
+
+// groupBySegment hashes each key once, with a single hasher, and groups the
+// keys by their segment. Keys that cannot be hashed are left out, as the
+// single-key operations ignore them.
+func (c *Cache[K, V]) groupBySegment(keys []K) map[*Segment[K, V]][]K {
+	hasher := c.hashFunc()
+	groups := make(map[*Segment[K, V]][]K)
+	for _, key := range keys {
+		if segment := c.segmentFor(hasher, key); segment != nil {
+			groups[segment] = append(groups[segment], key)
+		}
+	}
+	return groups
+}
+
+// getMany retrieves copies of the items for keys, like get, appending the keys
+// found to hits and their items to items. Items a read leaves unchanged are
+// collected under the read lock if the policy allows concurrent access; all
+// others are collected under the write lock, taken once for all of them.
+func (s *Segment[K, V]) getMany(keys []K, hits []K, items []Item[V]) ([]K, []Item[V]) {
+	s.markUsed()
+	pending := keys
+	if s.concurrentAccess {
+		pending = nil
+		s.lock.RLock()
+		for _, key := range keys {
+			item, exists := s.items[key]
+			switch {
+			case !exists:
+			case !item.Expired() && item.idle == 0 && s.cache.expiry == nil:
+				s.policy.OnAccess(key)
+				hits, items = append(hits, key), append(items, *item)
+			default:
+				pending = append(pending, key)
+			}
+		}
+		s.lock.RUnlock()
+	}
+
+	if len(pending) == 0 {
+		return hits, items
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, key := range pending {
+		if item, found := s.getLocked(key); found {
+			hits, items = append(hits, key), append(items, item)
+		}
+	}
+	return hits, items
+}
+
+// setMany sets the values of keys, weighing weights, under a single write
+// lock. It returns the first error of setLocked, but sets all other keys.
+func (s *Segment[K, V]) setMany(keys []K, values map[K]V, weights []int64, options writeOptions, defaultExpiration time.Duration) error {
+	var err error
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, key := range keys {
+		if _, _, setErr := s.setLocked(key, values[key], weights[i], options, defaultExpiration); setErr != nil && err == nil {
+			err = setErr
+		}
+	}
+	return err
+}
+
+// deleteMany removes keys under a single write lock.
+func (s *Segment[K, V]) deleteMany(keys []K) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, key := range keys {
+		s.removeKey(key)
+	}
+}
@@ -0,0 +1,151 @@
+package swiftcache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetMany(t *testing.T) {
+	for _, policy := range []string{"LRU", "FIFO", "SIEVE"} {
+		tc, _ := New[string, int](Config[string, int]{SegmentCount: 4, EvictionPolicy: policy})
+
+		keys := make([]string, 0, 101)
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprint("key", i)
+			tc.Set(key, i, NoExpiration)
+			keys = append(keys, key)
+		}
+		tc.Set("expired", -1, time.Nanosecond)
+		keys = append(keys, "missing", "expired")
+		<-time.After(time.Millisecond)
+
+		values := tc.GetMany(keys)
+		if len(values) != 100 {
+			t.Errorf("%s: got %d values, expected 100", policy, len(values))
+		}
+		for i := 0; i < 100; i++ {
+			if v, found := values[fmt.Sprint("key", i)]; !found || v != i {
+				t.Errorf("%s: got %d, %v for key%d", policy, v, found, i)
+			}
+		}
+		if _, found := values["expired"]; found {
+			t.Errorf("%s: GetMany returned an expired item", policy)
+		}
+	}
+}
+
+func TestGetManySliding(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{SlidingExpiration: true, EvictionPolicy: "SIEVE"})
+
+	tc.Set("a", 1, time.Hour)
+	_, first, _ := tc.GetWithExpiration("a")
+	<-time.After(5 * time.Millisecond)
+
+	if values := tc.GetMany([]string{"a"}); values["a"] != 1 {
+		t.Fatalf("got %v, expected a", values)
+	}
+	if _, extended, _ := tc.GetWithExpiration("a"); !extended.After(first) {
+		t.Error("GetMany did not extend the expiration of a sliding item")
+	}
+}
+
+func TestGetManyRecordsAccess(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{SegmentCount: 1, MaxCacheSize: 2, EvictionPolicy: "LRU"})
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, NoExpiration)
+	tc.GetMany([]string{"a"})
+	tc.Set("c", 3, NoExpiration)
+
+	if _, found := tc.Get("a"); !found {
+		t.Error("the item read by GetMany was evicted")
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("the least recently used item was not evicted")
+	}
+}
+
+func TestSetMany(t *testing.T) {
+	tc, _ := New[int, string](Config[int, string]{SegmentCount: 4})
+
+	items := make(map[int]string)
+	for i := 0; i < 100; i++ {
+		items[i] = fmt.Sprint(i)
+	}
+	if err := tc.SetMany(items, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if n := tc.ItemCount(); n != 100 {
+		t.Errorf("got %d items, expected 100", n)
+	}
+	for i := 0; i < 100; i++ {
+		v, expiration, found := tc.GetWithExpiration(i)
+		if !found || v != items[i] || expiration.IsZero() {
+			t.Errorf("got %q expiring at %v, %v for %d", v, expiration, found, i)
+		}
+	}
+}
+
+func TestSetManyTooLarge(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		SegmentCount: 1,
+		MaxBytes:     10,
+		Weigher:      func(key string, value int) int64 { return int64(value) },
+	})
+
+	err := tc.SetMany(map[string]int{"a": 1, "b": 20, "c": 3}, NoExpiration)
+	if !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("got %v, expected ErrItemTooLarge", err)
+	}
+	if values := tc.GetMany([]string{"a", "b", "c"}); len(values) != 2 || values["a"] != 1 || values["c"] != 3 {
+		t.Errorf("got %v, expected a and c only", values)
+	}
+}
+
+func TestSetManyBudget(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4, MaxItems: 10})
+
+	items := make(map[string]any)
+	for i := 0; i < 50; i++ {
+		items[fmt.Sprint(i)] = i
+	}
+	tc.SetMany(items, NoExpiration)
+	if n := tc.ItemCount(); n > 10 {
+		t.Errorf("got %d items, expected at most 10", n)
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4})
+
+	var evicted []string
+	tc.OnEvicted(func(key string, value any) {
+		evicted = append(evicted, key)
+	})
+
+	tc.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}, NoExpiration)
+	tc.DeleteMany([]string{"a", "c", "missing"})
+
+	if values := tc.GetMany([]string{"a", "b", "c"}); len(values) != 1 || values["b"] != 2 {
+		t.Errorf("got %v, expected b only", values)
+	}
+	if len(evicted) != 2 {
+		t.Errorf("got %v evicted, expected a and c", evicted)
+	}
+}
+
+func BenchmarkCacheGetMany(b *testing.B) {
+	tc, _ := NewCache()
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+		tc.Set(keys[i], i, NoExpiration)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tc.GetMany(keys)
+	}
+}
@@ -0,0 +1,118 @@
+package swiftcache
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+)
+
+const reclaimSamples = 4 // Segments reclaim compares when choosing where to evict
+
+// budget is a capacity shared by all segments of a cache, configured through
+// Config.MaxItems and Config.MaxTotalBytes. Segments update it on every
+// change, so the cache as a whole holds at most the configured number of
+// items and weight, however unevenly keys are spread across segments.
+type budget struct {
+	maxItems  int64         // Maximum number of items, 0 if unlimited
+	maxWeight int64         // Maximum total weight, 0 if unlimited
+	items     atomic.Int64  // Current number of items
+	weight    atomic.Int64  // Current total weight
+	cursor    atomic.Uint32 // Next segment reclaim samples
+	clock     atomic.Uint64 // Number of evictions made by reclaim so far
+}
+
+// newBudget creates a budget for at most maxItems items and maxWeight weight.
+func newBudget(maxItems int, maxWeight int64) *budget {
+	return &budget{
+		maxItems:  int64(maxItems),
+		maxWeight: maxWeight,
+	}
+}
+
+// add records a change in the number of items and the total weight.
+func (b *budget) add(items int, weight int64) {
+	if items != 0 {
+		b.items.Add(int64(items))
+	}
+	if weight != 0 {
+		b.weight.Add(weight)
+	}
+}
+
+// exceeded reports whether the cache holds more than the budget allows.
+func (b *budget) exceeded() bool {
+	return (b.maxItems > 0 && b.items.Load() > b.maxItems) ||
+		(b.maxWeight > 0 && b.weight.Load() > b.maxWeight)
+}
+
+// tooLarge reports whether a single item of the given weight exceeds the budget.
+func (b *budget) tooLarge(weight int64) bool {
+	return b.maxWeight > 0 && weight > b.maxWeight
+}
+
+// reclaim evicts items until the shared budget, if any, fits again. Each
+// eviction is taken from the segment that went unused longest among origin,
+// the segment just written, and reclaimSamples others visited round robin,
+// where the budget's clock measures time in evictions. Segments that turn
+// cold thus hand their room over to busy ones, instead of keeping whatever
+// share they held when the budget filled up. Origin is only picked when no
+// sampled segment is colder, and always keeps the item just written.
+func (c *Cache[K, V]) reclaim(origin *Segment[K, V]) {
+	if c.budget == nil {
+		return
+	}
+
+	var buf [reclaimSamples]sampledSegment[K, V]
+	idle := 0 // Segments visited in a row without evicting anything
+	for c.budget.exceeded() && idle < c.segmentCount {
+		samples := c.sample(origin, buf[:0])
+		idle += reclaimSamples
+
+		evicted := false
+		hot := origin.used.Load()
+		for _, sample := range samples {
+			if sample.used >= hot {
+				break // Not colder than origin
+			}
+			if evicted = sample.segment.evict(0); evicted {
+				break
+			}
+		}
+		if !evicted {
+			evicted = origin.evict(1)
+		}
+		for i := 0; !evicted && i < len(samples); i++ {
+			evicted = samples[i].segment.evict(0)
+		}
+
+		if evicted {
+			c.budget.clock.Add(1)
+			idle = 0
+		}
+	}
+}
+
+// sampledSegment is a segment considered by reclaim, with its last use.
+type sampledSegment[K comparable, V any] struct {
+	segment *Segment[K, V]
+	used    uint64
+}
+
+// sample appends to samples up to reclaimSamples distinct segments other than
+// origin, visited round robin, ordered from the one that went unused longest.
+func (c *Cache[K, V]) sample(origin *Segment[K, V], samples []sampledSegment[K, V]) []sampledSegment[K, V] {
+	mask := uint32(c.segmentCount) - 1
+	start := c.budget.cursor.Add(reclaimSamples)
+
+	for i := uint32(0); i < reclaimSamples; i++ {
+		segment := c.segments[(start-i)&mask]
+		if segment == origin || slices.ContainsFunc(samples, func(s sampledSegment[K, V]) bool { return s.segment == segment }) {
+			continue
+		}
+		samples = append(samples, sampledSegment[K, V]{segment: segment, used: segment.used.Load()})
+	}
+	slices.SortStableFunc(samples, func(a, b sampledSegment[K, V]) int {
+		return cmp.Compare(a.used, b.used)
+	})
+	return samples
+}
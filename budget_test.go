@@ -0,0 +1,162 @@
+package swiftcache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// keysInSegment returns n keys that all hash to the given segment.
+func keysInSegment(c *Cache[string, any], segment, n int) []string {
+	var keys []string
+	for i := 0; len(keys) < n; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if getSegmentIndex(c, key) == segment {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestCacheMaxItems(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 16, MaxItems: 100})
+	for i := 0; i < 1000; i++ {
+		tc.Set(fmt.Sprintf("key%d", i), i, NoExpiration)
+	}
+	if n := tc.ItemCount(); n != 100 {
+		t.Errorf("Item count is not 100: %d", n)
+	}
+}
+
+func TestCacheMaxItemsSkewed(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4, MaxCacheSize: 10, MaxItems: 100})
+
+	// All keys land in one segment, which borrows the whole budget
+	for _, key := range keysInSegment(tc, 0, 150) {
+		tc.Set(key, 1, NoExpiration)
+	}
+	if n := tc.segments[0].size; n != 100 {
+		t.Errorf("skewed segment holds %d items, expected 100", n)
+	}
+	if n := tc.ItemCount(); n != 100 {
+		t.Errorf("Item count is not 100: %d", n)
+	}
+}
+
+func TestCacheMaxItemsReclaim(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4, MaxItems: 30})
+	for segment := 1; segment < 4; segment++ {
+		for _, key := range keysInSegment(tc, segment, 10) {
+			tc.Set(key, 1, NoExpiration)
+		}
+	}
+
+	// The empty segment has nothing to evict, so another segment makes room
+	key := keysInSegment(tc, 0, 1)[0]
+	tc.Set(key, 1, NoExpiration)
+	if _, found := tc.Get(key); !found {
+		t.Errorf("%s was not stored", key)
+	}
+	if n := tc.ItemCount(); n != 30 {
+		t.Errorf("Item count is not 30: %d", n)
+	}
+}
+
+func TestCacheMaxTotalBytes(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 8, MaxBytes: 5, MaxTotalBytes: 100})
+	for i := 0; i < 100; i++ {
+		tc.Set(fmt.Sprintf("key%d", i), sizedBlob("0123456789"), NoExpiration)
+	}
+	if n := tc.ItemCount(); n != 10 {
+		t.Errorf("Item count is not 10: %d", n)
+	}
+	if w := tc.budget.weight.Load(); w != 100 {
+		t.Errorf("total weight is %d, expected 100", w)
+	}
+
+	// The per-segment MaxBytes is replaced by the cache-wide limit
+	if err := tc.Set("large", sizedBlob("0123456789012345678901234567890123456789"), NoExpiration); err != nil {
+		t.Error("Set rejected an item that fits the cache-wide limit:", err)
+	}
+	if err := tc.Set("huge", make(sizedBlob, 101), NoExpiration); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("Set returned %v, expected ErrItemTooLarge", err)
+	}
+
+	tc.Flush()
+	if n, w := tc.budget.items.Load(), tc.budget.weight.Load(); n != 0 || w != 0 {
+		t.Errorf("budget holds %d items of weight %d after Flush", n, w)
+	}
+}
+
+func TestCacheMaxItemsRebalances(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 2, MaxItems: 10})
+
+	for _, key := range keysInSegment(tc, 0, 10) {
+		tc.Set(key, 1, NoExpiration)
+	}
+
+	// Segment 0 went cold, so the busy segment takes over its room
+	for _, key := range keysInSegment(tc, 1, 20) {
+		tc.Set(key, 1, NoExpiration)
+	}
+	if n := tc.segments[1].size; n != 10 {
+		t.Errorf("busy segment holds %d items, expected 10", n)
+	}
+	if n := tc.segments[0].size; n != 0 {
+		t.Errorf("cold segment holds %d items, expected 0", n)
+	}
+
+	// And gets some back once it is busy again
+	for _, key := range keysInSegment(tc, 0, 20)[10:] {
+		tc.Set(key, 1, NoExpiration)
+	}
+	if n := tc.segments[0].size; n < 5 {
+		t.Errorf("segment 0 holds %d items after turning busy again, expected at least 5", n)
+	}
+	if n := tc.ItemCount(); n != 10 {
+		t.Errorf("Item count is not 10: %d", n)
+	}
+}
+
+func TestCacheMaxItemsKeepsHotSegment(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 2, MaxItems: 10})
+
+	hot := keysInSegment(tc, 0, 5)
+	for _, key := range hot {
+		tc.Set(key, 1, NoExpiration)
+	}
+	for _, key := range keysInSegment(tc, 1, 30) {
+		for _, h := range hot {
+			tc.Get(h)
+		}
+		tc.Set(key, 1, NoExpiration)
+	}
+	for _, key := range hot {
+		if _, found := tc.Get(key); !found {
+			t.Errorf("%s was evicted although its segment is read all the time", key)
+		}
+	}
+}
+
+func TestCacheMaxItemsResizesPolicy(t *testing.T) {
+	for _, policy := range []string{"ARC", "TinyLFU", "S3-FIFO"} {
+		tc, _ := NewCache(CacheConfig{SegmentCount: 4, MaxItems: 100, EvictionPolicy: policy})
+		segment := tc.segments[0]
+		if segment.policyCapacity != 25 {
+			t.Fatalf("%s: policy sized for %d items, expected the fair share of 25", policy, segment.policyCapacity)
+		}
+
+		keys := keysInSegment(tc, 0, 100)
+		for _, key := range keys {
+			tc.Set(key, 1, NoExpiration)
+		}
+		if segment.policyCapacity != 100 {
+			t.Errorf("%s: policy sized for %d items after borrowing the whole budget, expected 100", policy, segment.policyCapacity)
+		}
+
+		tc.DeleteMany(keys)
+		if segment.policyCapacity != 25 {
+			t.Errorf("%s: policy sized for %d items once empty again, expected 25", policy, segment.policyCapacity)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package swiftcache
 import (
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"runtime"
 	"strconv"
@@ -103,6 +104,48 @@ func TestCacheTimes(t *testing.T) {
 	}
 }
 
+func TestCacheDefaultExpirationModes(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{DefaultExpiration: 20 * time.Millisecond})
+	tc.Set("default", 1, DefaultExpiration)
+	tc.Set("never", 2, NoExpiration)
+	tc.Set("explicit", 3, 100*time.Millisecond)
+
+	_, expiration, _ := tc.GetWithExpiration("default")
+	if expiration.IsZero() {
+		t.Error("default did not pick up the configured default expiration")
+	}
+	_, expiration, _ = tc.GetWithExpiration("never")
+	if !expiration.IsZero() {
+		t.Error("never has an expiration even though it was set with NoExpiration")
+	}
+
+	<-time.After(40 * time.Millisecond)
+	if _, found := tc.Get("default"); found {
+		t.Error("Found default when it should have expired with the configured default")
+	}
+	if _, found := tc.Get("never"); !found {
+		t.Error("Did not find never even though it was set to never expire")
+	}
+	if _, found := tc.Get("explicit"); !found {
+		t.Error("Did not find explicit even though its own ttl has not passed")
+	}
+
+	<-time.After(80 * time.Millisecond)
+	if _, found := tc.Get("explicit"); found {
+		t.Error("Found explicit when it should have expired with its own ttl")
+	}
+}
+
+func TestCacheNoDefaultExpiration(t *testing.T) {
+	for _, de := range []time.Duration{0, NoExpiration} {
+		tc, _ := NewCache(CacheConfig{DefaultExpiration: de})
+		tc.Set("a", 1, DefaultExpiration)
+		if _, expiration, found := tc.GetWithExpiration("a"); !found || !expiration.IsZero() {
+			t.Errorf("DefaultExpiration %v: a should never expire, got expiration %v", de, expiration)
+		}
+	}
+}
+
 func TestIncrementWithInt(t *testing.T) {
 	tc, _ := NewCache()
 	tc.Set("tint", 1, DefaultExpiration)
@@ -591,7 +634,7 @@ func TestDecrementUnderflowUint(t *testing.T) {
 func TestOnEvicted(t *testing.T) {
 	tc, _ := NewCache()
 	tc.Set("foo", 3, DefaultExpiration)
-	if tc.onEvicted != nil {
+	if tc.onEvicted.Load() != nil {
 		t.Fatal("tc.onEvicted is not nil")
 	}
 	works := false
@@ -735,6 +778,107 @@ func TestStorePointerToStruct(t *testing.T) {
 	}
 }
 
+func TestTypedCache(t *testing.T) {
+	tc, err := New[int, string]()
+	if err != nil {
+		t.Fatal("Error creating typed cache:", err)
+	}
+
+	if v, found := tc.Get(1); found || v != "" {
+		t.Error("Getting 1 found value that shouldn't exist:", v)
+	}
+
+	tc.Set(1, "one", NoExpiration)
+	tc.Set(2, "two", 50*time.Millisecond)
+
+	v, found := tc.Get(1)
+	if !found {
+		t.Error("1 was not found")
+	}
+	if v+"!" != "one!" {
+		t.Error("value for 1 is not one:", v)
+	}
+
+	v, expiration, found := tc.GetWithExpiration(2)
+	if !found || v != "two" {
+		t.Error("2 was not found or has the wrong value:", v)
+	}
+	if expiration.IsZero() {
+		t.Error("expiration for 2 is a zeroed time")
+	}
+
+	items := tc.Items()
+	if len(items) != 2 || items[1] != "one" || items[2] != "two" {
+		t.Error("Items returned unexpected contents:", items)
+	}
+
+	tc.Delete(1)
+	if _, found := tc.Get(1); found {
+		t.Error("1 was found, but it should have been deleted")
+	}
+}
+
+func TestTypedCacheStructKey(t *testing.T) {
+	type point struct{ X, Y int }
+
+	tc, _ := New[point, *TestStruct](Config[point, *TestStruct]{SegmentCount: 16})
+	for i := 0; i < 100; i++ {
+		tc.Set(point{i, -i}, &TestStruct{Num: i}, NoExpiration)
+	}
+	for i := 0; i < 100; i++ {
+		x, found := tc.Get(point{i, -i})
+		if !found {
+			t.Fatalf("point{%d, %d} was not found", i, -i)
+		}
+		if x.Num != i {
+			t.Errorf("point{%d, %d} has Num %d", i, -i, x.Num)
+		}
+	}
+}
+
+func TestTypedCacheNestedFloatKeyZero(t *testing.T) {
+	type key struct {
+		X float64
+		A [2]float32
+		I any
+		_ int
+	}
+
+	tc, _ := New[key, int](Config[key, int]{SegmentCount: 64})
+	negZero := math.Copysign(0, -1)
+	for i := 0; i < 100; i++ {
+		tc.Set(key{X: negZero, A: [2]float32{float32(negZero), float32(i)}, I: negZero}, i, NoExpiration)
+	}
+	for i := 0; i < 100; i++ {
+		if x, found := tc.Get(key{A: [2]float32{0, float32(i)}, I: 0.0}); !found || x != i {
+			t.Errorf("+0 did not find the value %d stored under -0", i)
+		}
+	}
+}
+
+func TestTypedCacheFloatKeyZero(t *testing.T) {
+	tc, _ := New[float64, int](Config[float64, int]{SegmentCount: 64})
+	negZero := math.Copysign(0, -1)
+	tc.Set(negZero, 1, NoExpiration)
+	if x, found := tc.Get(0); !found || x != 1 {
+		t.Error("+0 did not find the value stored under -0")
+	}
+}
+
+func TestTypedCacheIncrement(t *testing.T) {
+	tc, _ := New[string, int64]()
+	tc.Set("counter", 1, NoExpiration)
+	if err := tc.Increment("counter", 2); err != nil {
+		t.Error("Error incrementing:", err)
+	}
+	if err := tc.Decrement("counter", 1); err != nil {
+		t.Error("Error decrementing:", err)
+	}
+	if x, _ := tc.Get("counter"); x != 2 {
+		t.Error("counter is not 2:", x)
+	}
+}
+
 // Test LRU eviction policy
 func TestCacheLRUEviction(t *testing.T) {
 	maxSize := 5
@@ -784,6 +928,13 @@ func TestCacheFIFOEviction(t *testing.T) {
 	}
 }
 
+func TestUnknownEvictionPolicy(t *testing.T) {
+	_, err := NewCache(CacheConfig{EvictionPolicy: "MRU"})
+	if err == nil {
+		t.Error("NewCache accepted an unknown eviction policy")
+	}
+}
+
 func TestCacheLRUAndFIFOEviction(t *testing.T) {
 	segmentCount := 4
 	maxSize := 5
@@ -792,7 +943,7 @@ func TestCacheLRUAndFIFOEviction(t *testing.T) {
 }
 
 // Returns the segment index for a given key
-func getSegmentIndex(c *Cache, key string) int {
+func getSegmentIndex(c *Cache[string, any], key string) int {
 	hasher := c.hashFunc()
 	_, err := hasher.Write([]byte(key))
 	if err != nil {
@@ -813,7 +964,7 @@ func allSegmentsFilled(segmentFill []int, maxSize int) bool {
 }
 
 // printCacheContents prints the contents of the cache for debugging
-func printCacheContents(c *Cache) {
+func printCacheContents(c *Cache[string, any]) {
 	fmt.Println("Cache contents:")
 	for i, segment := range c.segments {
 		fmt.Printf("Segment %d:\n", i)
@@ -1129,3 +1280,86 @@ func BenchmarkMapSetAndGetManyConcurrent(b *testing.B) {
 	hitRate := float64(totalHits) / float64(b.N)
 	b.Logf("Total operations: %d, Hit Rate: %.2f%%", b.N, hitRate*100)
 }
+
+func TestSlidingExpiration(t *testing.T) {
+	for _, policy := range []string{"LRU", "SIEVE"} {
+		tc, _ := New[string, int](Config[string, int]{
+			EvictionPolicy:    policy,
+			SlidingExpiration: true,
+		})
+
+		tc.Set("a", 1, time.Hour)
+		_, first, _ := tc.GetWithExpiration("a")
+		<-time.After(5 * time.Millisecond)
+
+		// GetWithExpiration does not count as a hit, Get does
+		if _, again, _ := tc.GetWithExpiration("a"); !again.Equal(first) {
+			t.Errorf("%s: GetWithExpiration extended the expiration", policy)
+		}
+		tc.Get("a")
+		if _, extended, _ := tc.GetWithExpiration("a"); !extended.After(first) {
+			t.Errorf("%s: a hit did not extend the expiration", policy)
+		}
+	}
+}
+
+func TestSlidingExpirationExpiresWhenIdle(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{SlidingExpiration: true})
+
+	tc.Set("a", 1, 50*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		<-time.After(20 * time.Millisecond)
+		if _, found := tc.Get("a"); !found {
+			t.Fatalf("a sliding item read every 20ms expired after %d reads", i)
+		}
+	}
+
+	<-time.After(70 * time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("a sliding item did not expire after being idle for its ttl")
+	}
+}
+
+func TestSlidingExpirationMaxLifetime(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		SlidingExpiration: true,
+		MaxLifetime:       time.Minute,
+	})
+
+	before := time.Now()
+	tc.Set("a", 1, time.Hour)
+	tc.Get("a")
+	_, expiration, _ := tc.GetWithExpiration("a")
+	if expiration.Sub(before) > time.Minute+time.Second {
+		t.Errorf("a sliding item expires after %v, beyond its maximum lifetime", expiration.Sub(before))
+	}
+
+	tc.SetSliding("c", 3, 30*time.Millisecond, 80*time.Millisecond)
+	found := true
+	for start := time.Now(); time.Since(start) < 200*time.Millisecond && found; {
+		<-time.After(10 * time.Millisecond)
+		_, found = tc.Get("c")
+	}
+	if found {
+		t.Error("an item read constantly outlived its maximum lifetime")
+	}
+}
+
+func TestSetSliding(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("fixed", 1, time.Hour)
+	tc.SetSliding("sliding", 2, time.Hour, 0)
+	_, fixed, _ := tc.GetWithExpiration("fixed")
+	_, sliding, _ := tc.GetWithExpiration("sliding")
+	<-time.After(5 * time.Millisecond)
+
+	tc.Get("fixed")
+	tc.Get("sliding")
+	if _, after, _ := tc.GetWithExpiration("fixed"); !after.Equal(fixed) {
+		t.Error("a hit extended an item that does not slide")
+	}
+	if _, after, _ := tc.GetWithExpiration("sliding"); !after.After(sliding) {
+		t.Error("a hit did not extend an item set with SetSliding")
+	}
+}
@@ -0,0 +1,64 @@
+package swiftcache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrVersionMismatch is returned by CompareAndSwap and CompareAndDelete when
+// the item was written since its version was read.
+var ErrVersionMismatch = errors.New("item version does not match")
+
+// GetWithVersion is like Get, but also returns the version of the value, a
+// token for CompareAndSwap and CompareAndDelete. Every write of a key gives
+// it a new, higher version. It does not load missing keys.
+func (c *Cache[K, V]) GetWithVersion(key K) (V, uint64, bool) {
+	segment := c.getSegment(key)
+	if segment == nil {
+		var zero V
+		return zero, 0, false
+	}
+	item, found := segment.get(key)
+	return item.Value, item.version, found
+}
+
+// CompareAndSwap sets a new value for key only if its version is still
+// version, as returned by GetWithVersion, like memcached's cas. It returns
+// ErrVersionMismatch if the key was written in the meantime, and
+// ErrKeyNotFound if it is absent or expired, leaving the cache unchanged.
+func (c *Cache[K, V]) CompareAndSwap(key K, version uint64, value V, ttl time.Duration) error {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return ErrKeyNotFound
+	}
+	options := c.writeOptions(ttl)
+	options.condition, options.version = ifVersion, version
+	_, _, err := c.set(segment, key, value, options)
+	return err
+}
+
+// CompareAndDelete removes key only if its version is still version, as
+// returned by GetWithVersion. It returns ErrVersionMismatch if the key was
+// written in the meantime, and ErrKeyNotFound if it is absent or expired.
+func (c *Cache[K, V]) CompareAndDelete(key K, version uint64) error {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return ErrKeyNotFound
+	}
+
+	segment.lock.Lock()
+	defer segment.lock.Unlock()
+
+	item, exists := segment.items[key]
+	switch {
+	case !exists:
+		return ErrKeyNotFound
+	case item.Expired():
+		segment.removeKey(key)
+		return ErrKeyNotFound
+	case item.version != version:
+		return ErrVersionMismatch
+	}
+	segment.removeKey(key)
+	return nil
+}
@@ -0,0 +1,127 @@
+package swiftcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetWithVersion(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if _, _, found := tc.GetWithVersion("a"); found {
+		t.Error("GetWithVersion found a missing key")
+	}
+
+	tc.Set("a", 1, NoExpiration)
+	_, first, found := tc.GetWithVersion("a")
+	if !found || first == 0 {
+		t.Fatalf("got version %d, %v; expected a version", first, found)
+	}
+	if _, again, _ := tc.GetWithVersion("a"); again != first {
+		t.Error("reading the key changed its version")
+	}
+
+	tc.Set("a", 1, NoExpiration)
+	_, second, _ := tc.GetWithVersion("a")
+	if second <= first {
+		t.Errorf("version went from %d to %d on a write, expected it to grow", first, second)
+	}
+
+	tc.Increment("a", 1)
+	if _, third, _ := tc.GetWithVersion("a"); third <= second {
+		t.Errorf("version went from %d to %d on Increment, expected it to grow", second, third)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("a", 1, NoExpiration)
+	_, version, _ := tc.GetWithVersion("a")
+
+	if err := tc.CompareAndSwap("a", version, 2, NoExpiration); err != nil {
+		t.Fatalf("CompareAndSwap with the current version returned %v", err)
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("got %d, expected the swapped value", v)
+	}
+
+	// The swap was a write, so the old version no longer matches
+	if err := tc.CompareAndSwap("a", version, 3, NoExpiration); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("CompareAndSwap with a stale version returned %v, expected ErrVersionMismatch", err)
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("got %d, a failed CompareAndSwap changed the value", v)
+	}
+
+	if err := tc.CompareAndSwap("missing", version, 1, NoExpiration); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("CompareAndSwap of a missing key returned %v, expected ErrKeyNotFound", err)
+	}
+
+	tc.Set("b", 1, time.Millisecond)
+	_, version, _ = tc.GetWithVersion("b")
+	<-time.After(5 * time.Millisecond)
+	if err := tc.CompareAndSwap("b", version, 2, NoExpiration); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("CompareAndSwap of an expired item returned %v, expected ErrKeyNotFound", err)
+	}
+}
+
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	type counter struct{ hits int }
+	tc, _ := New[string, counter]()
+	tc.Set("a", counter{}, NoExpiration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				c, version, _ := tc.GetWithVersion("a")
+				c.hits++
+				err := tc.CompareAndSwap("a", version, c, NoExpiration)
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, ErrVersionMismatch) {
+					t.Errorf("CompareAndSwap returned %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c, _ := tc.Get("a"); c.hits != 50 {
+		t.Errorf("got %d hits, expected 50; an update was lost", c.hits)
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("a", 1, NoExpiration)
+	_, version, _ := tc.GetWithVersion("a")
+	tc.Set("a", 2, NoExpiration)
+
+	if err := tc.CompareAndDelete("a", version); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("CompareAndDelete with a stale version returned %v, expected ErrVersionMismatch", err)
+	}
+	if _, found := tc.Get("a"); !found {
+		t.Error("a failed CompareAndDelete removed the key")
+	}
+
+	_, version, _ = tc.GetWithVersion("a")
+	if err := tc.CompareAndDelete("a", version); err != nil {
+		t.Errorf("CompareAndDelete with the current version returned %v", err)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("CompareAndDelete did not remove the key")
+	}
+
+	if err := tc.CompareAndDelete("a", version); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("CompareAndDelete of a missing key returned %v, expected ErrKeyNotFound", err)
+	}
+}
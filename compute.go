@@ -0,0 +1,92 @@
+package swiftcache
+
+import "time"
+
+// Action tells Compute what to do with the value returned by its function.
+type Action int
+
+const (
+	ActionKeep   Action = iota // Leave the cache unchanged and ignore the returned value
+	ActionStore                // Store the returned value with the returned ttl
+	ActionDelete               // Remove the key from the cache
+)
+
+// Compute atomically reads, modifies and writes the value of key. It calls f
+// with the current value and whether the key was found, expired items counting
+// as absent, and applies the returned Action: ActionStore stores the returned
+// value with the returned ttl, which follows the rules of Set (use KeepTTL to
+// keep the current expiration), ActionDelete removes the key, and ActionKeep
+// leaves the cache as it is. It returns the value of key afterwards and
+// whether the key is present.
+//
+// f runs while the key's segment is locked, so it must be fast and must not
+// use the cache itself. The error is ErrItemTooLarge if the value to store
+// is too large, in which case the cache is left unchanged.
+func (c *Cache[K, V]) Compute(key K, f func(old V, found bool) (V, time.Duration, Action)) (V, bool, error) {
+	segment := c.getSegment(key)
+	if segment == nil {
+		var zero V
+		return zero, false, nil
+	}
+	value, found, err := segment.compute(key, f)
+	if err == nil {
+		c.reclaim(segment)
+	}
+	return value, found, err
+}
+
+// ComputeIfAbsent is like Compute, but only calls f if key is absent or
+// expired. Otherwise it returns the current value and true.
+func (c *Cache[K, V]) ComputeIfAbsent(key K, f func() (V, time.Duration, Action)) (V, bool, error) {
+	return c.Compute(key, func(old V, found bool) (V, time.Duration, Action) {
+		if found {
+			return old, KeepTTL, ActionKeep
+		}
+		return f()
+	})
+}
+
+// ComputeIfPresent is like Compute, but only calls f if key is present and
+// not expired. Otherwise it returns the zero value and false.
+func (c *Cache[K, V]) ComputeIfPresent(key K, f func(old V) (V, time.Duration, Action)) (V, bool, error) {
+	return c.Compute(key, func(old V, found bool) (V, time.Duration, Action) {
+		if !found {
+			return old, KeepTTL, ActionKeep
+		}
+		return f(old)
+	})
+}
+
+// compute implements Compute within the segment, holding its write lock
+// while f runs.
+func (s *Segment[K, V]) compute(key K, f func(old V, found bool) (V, time.Duration, Action)) (V, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var old V
+	item, found := s.items[key]
+	if found && item.Expired() {
+		s.removeKey(key)
+		found = false
+	}
+	if found {
+		old = item.Value
+	}
+
+	value, ttl, action := f(old, found)
+	switch action {
+	case ActionStore:
+		weight := s.cache.weigh(key, value)
+		if _, _, err := s.setLocked(key, value, weight, s.cache.writeOptions(ttl), s.cache.defaultExpiration); err != nil {
+			return old, found, err
+		}
+		return value, true, nil
+	case ActionDelete:
+		if found {
+			s.removeKey(key)
+		}
+		var zero V
+		return zero, false, nil
+	}
+	return old, found, nil
+}
@@ -0,0 +1,152 @@
+package swiftcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	tc, _ := New[string, []int]()
+
+	appendValue := func(n int) func(old []int, found bool) ([]int, time.Duration, Action) {
+		return func(old []int, found bool) ([]int, time.Duration, Action) {
+			return append(old, n), NoExpiration, ActionStore
+		}
+	}
+
+	if v, found, err := tc.Compute("a", appendValue(1)); err != nil || !found || len(v) != 1 {
+		t.Errorf("got %v, %v, %v; expected a new value", v, found, err)
+	}
+	if v, _, _ := tc.Compute("a", appendValue(2)); len(v) != 2 || v[1] != 2 {
+		t.Errorf("got %v, expected the value to be appended to", v)
+	}
+
+	v, found, _ := tc.Compute("a", func(old []int, found bool) ([]int, time.Duration, Action) {
+		return nil, NoExpiration, ActionKeep
+	})
+	if !found || len(v) != 2 {
+		t.Errorf("got %v, %v; ActionKeep changed the value", v, found)
+	}
+
+	if _, found, _ := tc.Compute("a", func(old []int, found bool) ([]int, time.Duration, Action) {
+		return nil, NoExpiration, ActionDelete
+	}); found {
+		t.Error("ActionDelete reported the key as present")
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("ActionDelete did not remove the key")
+	}
+}
+
+func TestComputeIsAtomic(t *testing.T) {
+	tc, _ := New[string, []int]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tc.Compute("a", func(old []int, found bool) ([]int, time.Duration, Action) {
+				return append(old, i), NoExpiration, ActionStore
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if v, _ := tc.Get("a"); len(v) != 100 {
+		t.Errorf("got %d values, expected 100; an update was lost", len(v))
+	}
+}
+
+func TestComputeExpired(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("a", 1, time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	tc.Compute("a", func(old int, found bool) (int, time.Duration, Action) {
+		if found || old != 0 {
+			t.Errorf("Compute passed %d, %v for an expired item", old, found)
+		}
+		return 0, NoExpiration, ActionKeep
+	})
+}
+
+func TestComputeKeepTTL(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("a", 1, time.Minute)
+	_, before, _ := tc.GetWithExpiration("a")
+	tc.Compute("a", func(old int, found bool) (int, time.Duration, Action) {
+		return old + 1, KeepTTL, ActionStore
+	})
+	if v, after, _ := tc.GetWithExpiration("a"); v != 2 || !after.Equal(before) {
+		t.Errorf("got %d expiring at %v, expected 2 expiring at %v", v, after, before)
+	}
+
+	tc.Compute("b", func(old int, found bool) (int, time.Duration, Action) {
+		return 1, KeepTTL, ActionStore
+	})
+	if ttl, found := tc.TTL("b"); !found || ttl != NoExpiration {
+		t.Errorf("got a ttl of %v for a new item stored with KeepTTL, expected NoExpiration", ttl)
+	}
+
+	// Increment is built on Compute and keeps the expiration too
+	tc.Increment("a", 1)
+	if v, after, _ := tc.GetWithExpiration("a"); v != 3 || !after.Equal(before) {
+		t.Errorf("got %d expiring at %v after Increment, expected 3 expiring at %v", v, after, before)
+	}
+}
+
+func TestComputeIfAbsent(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	calls := 0
+	create := func() (int, time.Duration, Action) {
+		calls++
+		return 1, NoExpiration, ActionStore
+	}
+
+	if v, found, _ := tc.ComputeIfAbsent("a", create); !found || v != 1 {
+		t.Errorf("got %d, %v; expected the computed value", v, found)
+	}
+	tc.Set("a", 2, NoExpiration)
+	if v, found, _ := tc.ComputeIfAbsent("a", create); !found || v != 2 {
+		t.Errorf("got %d, %v; expected the existing value", v, found)
+	}
+	if calls != 1 {
+		t.Errorf("f was called %d times, expected once", calls)
+	}
+}
+
+func TestComputeIfPresent(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	double := func(old int) (int, time.Duration, Action) {
+		return old * 2, KeepTTL, ActionStore
+	}
+
+	if _, found, _ := tc.ComputeIfPresent("a", double); found {
+		t.Error("ComputeIfPresent stored a missing key")
+	}
+	tc.Set("a", 2, NoExpiration)
+	if v, found, _ := tc.ComputeIfPresent("a", double); !found || v != 4 {
+		t.Errorf("got %d, %v; expected the doubled value", v, found)
+	}
+}
+
+func TestComputeTooLarge(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		MaxBytes: 10,
+		Weigher:  func(key string, value int) int64 { return int64(value) },
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	v, found, err := tc.Compute("a", func(old int, found bool) (int, time.Duration, Action) {
+		return 20, NoExpiration, ActionStore
+	})
+	if !errors.Is(err, ErrItemTooLarge) || !found || v != 1 {
+		t.Errorf("got %d, %v, %v; expected ErrItemTooLarge and the old value", v, found, err)
+	}
+}
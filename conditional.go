@@ -0,0 +1,68 @@
+package swiftcache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrKeyExists is returned when a write requires a key to be absent, but it is present.
+	ErrKeyExists = errors.New("key already exists")
+	// ErrKeyNotFound is returned when a write requires a key to be present, but it is absent.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// Add sets a key-value pair in the cache only if the key is absent, or its
+// item expired. Otherwise it returns ErrKeyExists, leaving the cache unchanged.
+func (c *Cache[K, V]) Add(key K, value V, ttl time.Duration) error {
+	_, _, err := c.setIf(ifAbsent, key, value, ttl)
+	return err
+}
+
+// Replace sets a new value for key only if the key is present and its item
+// has not expired. Otherwise it returns ErrKeyNotFound, leaving the cache
+// unchanged.
+func (c *Cache[K, V]) Replace(key K, value V, ttl time.Duration) error {
+	_, _, err := c.setIf(ifPresent, key, value, ttl)
+	return err
+}
+
+// SetIfAbsent sets a key-value pair in the cache only if the key is absent,
+// or its item expired. If the key is present, it returns its current value
+// and true, and leaves the cache unchanged; otherwise it returns value and
+// false. The error is ErrItemTooLarge if value could not be stored.
+func (c *Cache[K, V]) SetIfAbsent(key K, value V, ttl time.Duration) (V, bool, error) {
+	existing, found, err := c.setIf(ifAbsent, key, value, ttl)
+	switch {
+	case found:
+		return existing, true, nil
+	case err != nil:
+		return existing, false, err
+	}
+	return value, false, nil
+}
+
+// SetIfPresent sets a new value for key only if the key is present and its
+// item has not expired. It returns the value it replaced and true, or the zero
+// value and false if the key was absent, leaving the cache unchanged.
+// The error is ErrItemTooLarge if value could not be stored.
+func (c *Cache[K, V]) SetIfPresent(key K, value V, ttl time.Duration) (V, bool, error) {
+	previous, found, err := c.setIf(ifPresent, key, value, ttl)
+	if errors.Is(err, ErrKeyNotFound) {
+		err = nil
+	}
+	return previous, found, err
+}
+
+// setIf sets a key-value pair in the cache if condition holds, atomically
+// within the key's segment.
+func (c *Cache[K, V]) setIf(condition writeCondition, key K, value V, ttl time.Duration) (V, bool, error) {
+	segment := c.getSegment(key)
+	if segment == nil {
+		var zero V
+		return zero, false, nil
+	}
+	options := c.writeOptions(ttl)
+	options.condition = condition
+	return c.set(segment, key, value, options)
+}
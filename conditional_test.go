@@ -0,0 +1,130 @@
+package swiftcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAdd(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if err := tc.Add("a", 1, NoExpiration); err != nil {
+		t.Fatalf("Add of a new key returned %v", err)
+	}
+	if err := tc.Add("a", 2, NoExpiration); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Add of an existing key returned %v, expected ErrKeyExists", err)
+	}
+	if v, _ := tc.Get("a"); v != 1 {
+		t.Errorf("got %d, Add replaced an existing value", v)
+	}
+
+	// An expired item that was not removed yet counts as absent
+	tc.Set("b", 1, time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	if err := tc.Add("b", 2, NoExpiration); err != nil {
+		t.Errorf("Add over an expired item returned %v", err)
+	}
+	if v, _ := tc.Get("b"); v != 2 {
+		t.Errorf("got %d, expected the added value", v)
+	}
+}
+
+func TestAddIsAtomic(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	var added int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if tc.Add("a", i, NoExpiration) == nil {
+				atomic.AddInt32(&added, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if added != 1 {
+		t.Errorf("%d concurrent Adds succeeded, expected exactly one", added)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if err := tc.Replace("a", 1, NoExpiration); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Replace of a missing key returned %v, expected ErrKeyNotFound", err)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("Replace stored a missing key")
+	}
+
+	tc.Set("a", 1, NoExpiration)
+	if err := tc.Replace("a", 2, NoExpiration); err != nil {
+		t.Errorf("Replace of an existing key returned %v", err)
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("got %d, expected the replaced value", v)
+	}
+
+	tc.Set("b", 1, time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	if err := tc.Replace("b", 2, NoExpiration); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Replace of an expired item returned %v, expected ErrKeyNotFound", err)
+	}
+	if tc.ItemCount() != 1 {
+		t.Error("the expired item was not removed")
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if v, found, err := tc.SetIfAbsent("a", 1, NoExpiration); err != nil || found || v != 1 {
+		t.Errorf("got %d, %v, %v; expected the new value to be stored", v, found, err)
+	}
+	if v, found, err := tc.SetIfAbsent("a", 2, NoExpiration); err != nil || !found || v != 1 {
+		t.Errorf("got %d, %v, %v; expected the existing value", v, found, err)
+	}
+	if v, _ := tc.Get("a"); v != 1 {
+		t.Errorf("got %d, SetIfAbsent replaced an existing value", v)
+	}
+}
+
+func TestSetIfPresent(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if v, found, err := tc.SetIfPresent("a", 1, NoExpiration); err != nil || found || v != 0 {
+		t.Errorf("got %d, %v, %v; expected a miss", v, found, err)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("SetIfPresent stored a missing key")
+	}
+
+	tc.Set("a", 1, NoExpiration)
+	if v, found, err := tc.SetIfPresent("a", 2, NoExpiration); err != nil || !found || v != 1 {
+		t.Errorf("got %d, %v, %v; expected the replaced value", v, found, err)
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("got %d, expected the new value", v)
+	}
+}
+
+func TestConditionalSetTooLarge(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		MaxBytes: 10,
+		Weigher:  func(key string, value int) int64 { return int64(value) },
+	})
+
+	if _, _, err := tc.SetIfAbsent("a", 20, NoExpiration); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("got %v, expected ErrItemTooLarge", err)
+	}
+	tc.Set("b", 1, NoExpiration)
+	if _, _, err := tc.SetIfPresent("b", 20, NoExpiration); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("got %v, expected ErrItemTooLarge", err)
+	}
+}
@@ -0,0 +1,131 @@
+package swiftcache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// IncrementInt64 increases the value of an item of type int64 by n, keeping
+// its expiration, and returns the new value. It returns an error if the item
+// was not found or expired, or its value is not an int64. Overflow is handled
+// according to the cache's Overflow setting.
+func (c *Cache[K, V]) IncrementInt64(k K, n int64) (int64, error) {
+	mag, neg := magnitude(n)
+	return updateNumber(c, k, func(value int64) (int64, error) {
+		return addInteger(k, value, math.MinInt64, math.MaxInt64, mag, neg, "increment", c.overflow)
+	})
+}
+
+// IncrementUint64 increases the value of an item of type uint64 by n, keeping
+// its expiration, and returns the new value. It returns an error if the item
+// was not found or expired, or its value is not a uint64. Overflow is handled
+// according to the cache's Overflow setting.
+func (c *Cache[K, V]) IncrementUint64(k K, n uint64) (uint64, error) {
+	return updateNumber(c, k, func(value uint64) (uint64, error) {
+		return addInteger(k, value, 0, math.MaxUint64, n, false, "increment", c.overflow)
+	})
+}
+
+// IncrementFloat64 increases the value of an item of type float64 by n,
+// keeping its expiration, and returns the new value. It returns an error if
+// the item was not found or expired, or its value is not a float64.
+func (c *Cache[K, V]) IncrementFloat64(k K, n float64) (float64, error) {
+	return updateNumber(c, k, func(value float64) (float64, error) {
+		return addFloat(k, value, n, math.MaxFloat64, "increment", c.overflow)
+	})
+}
+
+// DecrementInt64 decreases the value of an item of type int64 by n, keeping
+// its expiration, and returns the new value. It returns an error if the item
+// was not found or expired, or its value is not an int64. Overflow is handled
+// according to the cache's Overflow setting.
+func (c *Cache[K, V]) DecrementInt64(k K, n int64) (int64, error) {
+	mag, neg := magnitude(n)
+	return updateNumber(c, k, func(value int64) (int64, error) {
+		return addInteger(k, value, math.MinInt64, math.MaxInt64, mag, !neg, "decrement", c.overflow)
+	})
+}
+
+// DecrementUint64 decreases the value of an item of type uint64 by n, keeping
+// its expiration, and returns the new value. It returns an error if the item
+// was not found or expired, or its value is not a uint64. Overflow is handled
+// according to the cache's Overflow setting; by default, decrementing below
+// zero fails with ErrOverflow.
+func (c *Cache[K, V]) DecrementUint64(k K, n uint64) (uint64, error) {
+	return updateNumber(c, k, func(value uint64) (uint64, error) {
+		return addInteger(k, value, 0, math.MaxUint64, n, true, "decrement", c.overflow)
+	})
+}
+
+// DecrementFloat64 decreases the value of an item of type float64 by n,
+// keeping its expiration, and returns the new value. It returns an error if
+// the item was not found or expired, or its value is not a float64.
+func (c *Cache[K, V]) DecrementFloat64(k K, n float64) (float64, error) {
+	return updateNumber(c, k, func(value float64) (float64, error) {
+		return addFloat(k, value, -n, math.MaxFloat64, "decrement", c.overflow)
+	})
+}
+
+// IncrementOrInit increases the numeric value of an item by n, keeping its
+// expiration, like Increment. If the item was not found or expired, it stores
+// initial with the given ttl instead, atomically, like memcached's incr with
+// an initial value. It returns the value of the item afterwards.
+func (c *Cache[K, V]) IncrementOrInit(k K, n int64, initial V, ttl time.Duration) (V, error) {
+	segment := c.getSegment(k)
+	if segment == nil {
+		var zero V
+		return zero, errors.New("key not found")
+	}
+
+	mag, neg := magnitude(n)
+	value, err := segment.incrementOrInit(k, func(value V) (any, error) {
+		return addNumber(k, any(value), mag, neg, "increment", c.overflow)
+	}, initial, c.writeOptions(ttl))
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	c.reclaim(segment)
+	return value, nil
+}
+
+// incrementOrInit replaces the numeric value of k by the result of f in place,
+// like computeNumber, or stores initial with options if k was not found or
+// expired. It returns the value of k afterwards.
+func (s *Segment[K, V]) incrementOrInit(k K, f func(value V) (any, error), initial V, options writeOptions) (V, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if value, found, err := s.computeNumberLocked(k, f); found {
+		return value, err
+	}
+	_, _, err := s.setLocked(k, initial, s.cache.weigh(k, initial), options, s.cache.defaultExpiration)
+	return initial, err
+}
+
+// updateNumber replaces the value of k, which must be of type T, by the result
+// of f, keeping its expiration, and returns the new value.
+func updateNumber[K comparable, V any, T int64 | uint64 | float64](c *Cache[K, V], k K, f func(value T) (T, error)) (T, error) {
+	var result T
+	segment := c.getSegment(k)
+	if segment == nil {
+		return result, errors.New("key not found")
+	}
+
+	err := segment.computeNumber(k, func(value V) (any, error) {
+		current, ok := any(value).(T)
+		if !ok {
+			return nil, fmt.Errorf("the value for %v is not of type %T", k, current)
+		}
+		var err error
+		result, err = f(current)
+		return result, err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package swiftcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrementInt64(t *testing.T) {
+	tc, _ := NewCache()
+
+	tc.Set("a", int64(1), time.Minute)
+	_, before, _ := tc.GetWithExpiration("a")
+	if v, err := tc.IncrementInt64("a", 2); err != nil || v != 3 {
+		t.Errorf("got %d, %v; expected 3", v, err)
+	}
+	if v, err := tc.DecrementInt64("a", 5); err != nil || v != -2 {
+		t.Errorf("got %d, %v; expected -2", v, err)
+	}
+	if v, after, _ := tc.GetWithExpiration("a"); v != int64(-2) || !after.Equal(before) {
+		t.Errorf("got %v expiring at %v, expected -2 expiring at %v", v, after, before)
+	}
+
+	tc.Set("int", 1, NoExpiration)
+	if _, err := tc.IncrementInt64("int", 1); err == nil {
+		t.Error("IncrementInt64 accepted a value of type int")
+	}
+	if _, err := tc.IncrementInt64("missing", 1); err == nil {
+		t.Error("IncrementInt64 accepted a missing key")
+	}
+}
+
+func TestIncrementUint64(t *testing.T) {
+	tc, _ := NewCache()
+
+	tc.Set("a", uint64(1), NoExpiration)
+	if v, err := tc.IncrementUint64("a", 2); err != nil || v != 3 {
+		t.Errorf("got %d, %v; expected 3", v, err)
+	}
+	if v, err := tc.DecrementUint64("a", 3); err != nil || v != 0 {
+		t.Errorf("got %d, %v; expected 0", v, err)
+	}
+	if _, err := tc.DecrementUint64("a", 1); err == nil {
+		t.Error("DecrementUint64 went below zero")
+	}
+	if v, _ := tc.Get("a"); v != uint64(0) {
+		t.Errorf("got %v, a failed decrement changed the value", v)
+	}
+}
+
+func TestIncrementFloat64(t *testing.T) {
+	tc, _ := NewCache()
+
+	tc.Set("a", 1.5, NoExpiration)
+	if v, err := tc.IncrementFloat64("a", 0.25); err != nil || v != 1.75 {
+		t.Errorf("got %v, %v; expected 1.75", v, err)
+	}
+	if v, err := tc.DecrementFloat64("a", 1.5); err != nil || v != 0.25 {
+		t.Errorf("got %v, %v; expected 0.25", v, err)
+	}
+}
+
+func TestIncrementInt64Concurrent(t *testing.T) {
+	tc, _ := New[string, int64]()
+	tc.Set("a", 0, NoExpiration)
+
+	seen := make([]bool, 101)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := tc.IncrementInt64("a", 1)
+			lock.Lock()
+			seen[v] = true
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Every increment observed its own result
+	for i := 1; i <= 100; i++ {
+		if !seen[i] {
+			t.Fatalf("no increment returned %d", i)
+		}
+	}
+}
+
+func TestIncrementOrInit(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	if v, err := tc.IncrementOrInit("a", 1, 10, time.Minute); err != nil || v != 10 {
+		t.Errorf("got %d, %v; expected the initial value", v, err)
+	}
+	_, before, _ := tc.GetWithExpiration("a")
+	if before.IsZero() {
+		t.Error("the initial value was not stored with the ttl")
+	}
+
+	if v, err := tc.IncrementOrInit("a", 5, 10, time.Hour); err != nil || v != 15 {
+		t.Errorf("got %d, %v; expected 15", v, err)
+	}
+	if _, after, _ := tc.GetWithExpiration("a"); !after.Equal(before) {
+		t.Error("incrementing an existing counter changed its expiration")
+	}
+
+	ts, _ := New[string, string]()
+	ts.Set("s", "text", NoExpiration)
+	if _, err := ts.IncrementOrInit("s", 1, "", NoExpiration); err == nil {
+		t.Error("IncrementOrInit accepted a value that is not a number")
+	}
+}
+
+func TestIncrementKeepsItemMetadata(t *testing.T) {
+	tc, _ := New[string, int64](Config[string, int64]{
+		SegmentCount:   1,
+		MaxCacheSize:   2,
+		EvictionPolicy: "LRU",
+	})
+
+	tc.SetWithDelta("a", 1, time.Hour, time.Second)
+	item, _ := tc.Item("a")
+	before := *item
+	tc.Set("b", 2, NoExpiration)
+
+	<-time.After(time.Millisecond)
+	if err := tc.Increment("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.IncrementInt64("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := tc.Item("a")
+	if after.delta != before.delta || after.written != before.written || after.Expiration != before.Expiration {
+		t.Errorf("increments changed delta %d, written %d, expiration %d to %d, %d, %d",
+			before.delta, before.written, before.Expiration, after.delta, after.written, after.Expiration)
+	}
+	if after.version <= before.version {
+		t.Error("increments did not give the value a new version")
+	}
+
+	// Increments do not count as a use of the key, so a is still the least recently used
+	tc.Set("c", 3, NoExpiration)
+	if _, found := tc.Get("a"); found {
+		t.Error("an increment moved a in the eviction order")
+	}
+}
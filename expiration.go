@@ -0,0 +1,92 @@
+package swiftcache
+
+// expirationEntry links an entry of the expiration heap back to its key,
+// so expired items can be removed from the segment map.
+type expirationEntry[K comparable, V any] struct {
+	key  K
+	item *Item[V]
+}
+
+// expirationHeap is a binary min-heap of the items of a segment ordered by
+// Item.Expiration. Only items that expire are indexed. Every item records its
+// position in heapIndex, so it can be fixed or removed in O(log n).
+type expirationHeap[K comparable, V any] []expirationEntry[K, V]
+
+func (h expirationHeap[K, V]) less(i, j int) bool {
+	return h[i].item.Expiration < h[j].item.Expiration
+}
+
+func (h expirationHeap[K, V]) swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].item.heapIndex = i
+	h[j].item.heapIndex = j
+}
+
+// push adds an item to the heap.
+func (h *expirationHeap[K, V]) push(key K, item *Item[V]) {
+	item.heapIndex = len(*h)
+	*h = append(*h, expirationEntry[K, V]{key: key, item: item})
+	h.up(item.heapIndex)
+}
+
+// remove removes the item at index i from the heap.
+func (h *expirationHeap[K, V]) remove(i int) {
+	n := len(*h) - 1
+	if i != n {
+		h.swap(i, n)
+	}
+	(*h)[n].item.heapIndex = -1
+	(*h)[n] = expirationEntry[K, V]{} // Drop the references for the GC
+	*h = (*h)[:n]
+	if i != n && !h.down(i) {
+		h.up(i)
+	}
+}
+
+// fix restores the heap order after the expiration of the item at index i changed.
+func (h expirationHeap[K, V]) fix(i int) {
+	if !h.down(i) {
+		h.up(i)
+	}
+}
+
+// peek returns the entry that expires first, if any.
+func (h expirationHeap[K, V]) peek() (expirationEntry[K, V], bool) {
+	if len(h) == 0 {
+		return expirationEntry[K, V]{}, false
+	}
+	return h[0], true
+}
+
+func (h expirationHeap[K, V]) up(j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !h.less(j, i) {
+			break
+		}
+		h.swap(i, j)
+		j = i
+	}
+}
+
+// down moves the entry at index i0 down the heap and reports whether it moved.
+func (h expirationHeap[K, V]) down(i0 int) bool {
+	i := i0
+	n := len(h)
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 { // j1 < 0 after int overflow
+			break
+		}
+		j := j1 // left child
+		if j2 := j1 + 1; j2 < n && h.less(j2, j1) {
+			j = j2 // right child
+		}
+		if !h.less(j, i) {
+			break
+		}
+		h.swap(i, j)
+		i = j
+	}
+	return i > i0
+}
@@ -0,0 +1,150 @@
+package swiftcache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkExpirationHeap verifies the heap order and that every item knows its position.
+func checkExpirationHeap(t *testing.T, s *Segment[string, any]) {
+	t.Helper()
+	h := s.expirations
+	for i := range h {
+		if h[i].item.heapIndex != i {
+			t.Fatalf("item %s has heapIndex %d, but is at %d", h[i].key, h[i].item.heapIndex, i)
+		}
+		if i > 0 && h[i].item.Expiration < h[(i-1)/2].item.Expiration {
+			t.Fatalf("heap order violated at index %d", i)
+		}
+	}
+	indexed := 0
+	for key, item := range s.items {
+		if item.Expiration > 0 {
+			indexed++
+			if item.heapIndex < 0 {
+				t.Fatalf("item %s expires but is not indexed", key)
+			}
+		} else if item.heapIndex >= 0 {
+			t.Fatalf("item %s never expires but is indexed", key)
+		}
+	}
+	if indexed != len(h) {
+		t.Fatalf("heap holds %d items, expected %d", len(h), indexed)
+	}
+}
+
+func TestExpirationHeapConsistency(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 1, MaxCacheSize: 200})
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%d", r.Intn(300))
+		switch r.Intn(4) {
+		case 0:
+			tc.Set(key, i, NoExpiration)
+		case 1, 2:
+			tc.Set(key, i, time.Duration(1+r.Intn(1000))*time.Hour)
+		case 3:
+			tc.Delete(key)
+		}
+	}
+	checkExpirationHeap(t, tc.segments[0])
+}
+
+func TestDeleteExpiredOnlyRemovesExpired(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4})
+	for i := 0; i < 1000; i++ {
+		if i%2 == 0 {
+			tc.Set(fmt.Sprintf("short%d", i), i, time.Millisecond)
+		} else {
+			tc.Set(fmt.Sprintf("long%d", i), i, time.Hour)
+		}
+	}
+
+	<-time.After(10 * time.Millisecond)
+	tc.DeleteExpired()
+	if n := tc.ItemCount(); n != 500 {
+		t.Errorf("Item count is not 500 after DeleteExpired: %d", n)
+	}
+	for _, segment := range tc.segments {
+		checkExpirationHeap(t, segment)
+	}
+}
+
+func TestJanitorFiresNearExpiry(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{CleanupInterval: time.Hour})
+	defer tc.Close()
+
+	evicted := make(chan time.Time, 1)
+	tc.OnEvicted(func(k string, v interface{}) {
+		evicted <- time.Now()
+	})
+
+	start := time.Now()
+	tc.Set("a", 1, 30*time.Millisecond)
+
+	select {
+	case at := <-evicted:
+		if at.Sub(start) < 30*time.Millisecond {
+			t.Error("a was evicted before it expired")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not evict a close to its expiration")
+	}
+}
+
+func TestJanitorSweepCountsEvictions(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 2, CleanupInterval: 5 * time.Millisecond})
+	defer tc.Close()
+
+	var evicted int32
+	tc.OnEvicted(func(k string, v interface{}) {
+		atomic.AddInt32(&evicted, 1)
+	})
+	for i := 0; i < 3*sweepBatchSize; i++ {
+		tc.Set(fmt.Sprintf("key%d", i), i, 10*time.Millisecond)
+	}
+
+	<-time.After(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&evicted); n != 3*sweepBatchSize {
+		t.Errorf("OnEvicted was called %d times, expected %d", n, 3*sweepBatchSize)
+	}
+}
+
+func TestDeleteExpiredSkipsSegmentsWithNothingDue(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 4})
+	for i := 0; i < 100; i++ {
+		tc.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+
+	// A sweep that locked a segment would block until the test releases it
+	for _, segment := range tc.segments {
+		segment.lock.Lock()
+	}
+	done := make(chan struct{})
+	go func() {
+		tc.DeleteExpired()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("DeleteExpired locked a segment with nothing due")
+	}
+	for _, segment := range tc.segments {
+		segment.lock.Unlock()
+	}
+	<-done
+
+	for _, segment := range tc.segments {
+		segment.lock.RLock()
+		entry, ok := segment.expirations.peek()
+		if next := segment.nextExpiration.Load(); ok && next != entry.item.Expiration || !ok && next != 0 {
+			t.Errorf("nextExpiration is %d, but the earliest expiration is %d", next, entry.item.Expiration)
+		}
+		segment.lock.RUnlock()
+	}
+}
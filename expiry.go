@@ -0,0 +1,42 @@
+package swiftcache
+
+import "time"
+
+// Expiry computes the ttl of every item individually, so that e.g. error
+// placeholders live for seconds and large objects for an hour without every
+// Set call site computing the ttl itself. Each method returns the item's new
+// ttl, which follows the rules of the ttl passed to Set: DefaultExpiration
+// uses the cache's default, NoExpiration never expires, and a positive ttl
+// expires after that duration. Returning the ttl or remaining argument as is
+// leaves the expiration unchanged.
+//
+// The methods are called while the item's segment is locked, so they must be
+// fast, safe for concurrent use, and must not use the cache themselves.
+type Expiry[K comparable, V any] interface {
+	// ExpireAfterCreate returns the ttl of a new item. ttl is the one the
+	// item was set with, with DefaultExpiration already applied.
+	ExpireAfterCreate(key K, value V, ttl time.Duration) time.Duration
+
+	// ExpireAfterUpdate returns the ttl of an item whose value was replaced.
+	// remaining is the time that was left until the old value expired, or
+	// NoExpiration.
+	ExpireAfterUpdate(key K, value V, ttl, remaining time.Duration) time.Duration
+
+	// ExpireAfterRead returns the ttl of an item after a hit by Get.
+	// remaining is the time left until the item expires, or NoExpiration.
+	ExpireAfterRead(key K, value V, remaining time.Duration) time.Duration
+}
+
+// remaining returns the time left at now until the item's ttl passes, not
+// counting any stale window, or NoExpiration if it never expires.
+func (item *Item[V]) remaining(now int64) time.Duration {
+	expiration := item.Expiration
+	if item.stale != 0 {
+		expiration = item.stale
+	}
+	if expiration == 0 {
+		return NoExpiration
+	}
+	// A live item always has some time left, and 0 would mean DefaultExpiration
+	return time.Duration(max(expiration-now, 1))
+}
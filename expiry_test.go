@@ -0,0 +1,113 @@
+package swiftcache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// placeholderExpiry keeps error placeholders for 5 seconds, keeps the
+// expiration of updated items, and extends items to a minute when read.
+type placeholderExpiry struct {
+	reads []time.Duration
+}
+
+func (e *placeholderExpiry) ExpireAfterCreate(key string, value string, ttl time.Duration) time.Duration {
+	if strings.HasPrefix(value, "error") {
+		return 5 * time.Second
+	}
+	return ttl
+}
+
+func (e *placeholderExpiry) ExpireAfterUpdate(key string, value string, ttl, remaining time.Duration) time.Duration {
+	return remaining
+}
+
+func (e *placeholderExpiry) ExpireAfterRead(key string, value string, remaining time.Duration) time.Duration {
+	e.reads = append(e.reads, remaining)
+	if key == "extended" {
+		return time.Minute
+	}
+	return remaining
+}
+
+// ttlOf returns the time left until key expires, or 0 if it never does.
+func ttlOf(tc *Cache[string, string], key string) time.Duration {
+	_, expiration, _ := tc.GetWithExpiration(key)
+	if expiration.IsZero() {
+		return 0
+	}
+	return time.Until(expiration)
+}
+
+func TestExpiryAfterCreate(t *testing.T) {
+	tc, _ := New[string, string](Config[string, string]{
+		DefaultExpiration: time.Hour,
+		Expiry:            &placeholderExpiry{},
+	})
+
+	tc.Set("user", "gopher", DefaultExpiration)
+	tc.Set("missing", "error: not found", DefaultExpiration)
+	tc.Set("forever", "gopher", NoExpiration)
+
+	if ttl := ttlOf(tc, "user"); ttl < 59*time.Minute {
+		t.Errorf("got a ttl of %v, expected the default of an hour", ttl)
+	}
+	if ttl := ttlOf(tc, "missing"); ttl > 5*time.Second || ttl < 4*time.Second {
+		t.Errorf("got a ttl of %v for a placeholder, expected 5s", ttl)
+	}
+	if ttl := ttlOf(tc, "forever"); ttl != 0 {
+		t.Errorf("got a ttl of %v, expected no expiration", ttl)
+	}
+}
+
+func TestExpiryAfterUpdate(t *testing.T) {
+	tc, _ := New[string, string](Config[string, string]{Expiry: &placeholderExpiry{}})
+
+	tc.Set("a", "1", time.Minute)
+	_, first, _ := tc.GetWithExpiration("a")
+	<-time.After(5 * time.Millisecond)
+
+	// Returning the remaining time keeps the expiration of the old value
+	tc.Set("a", "2", time.Hour)
+	if v, second, _ := tc.GetWithExpiration("a"); v != "2" || second.Sub(first).Abs() > time.Millisecond {
+		t.Errorf("got %q expiring %v after the original, expected the new value and the old expiration", v, second.Sub(first))
+	}
+
+	// An expired item is created anew
+	tc.Set("b", "1", time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	tc.Set("b", "2", time.Hour)
+	if ttl := ttlOf(tc, "b"); ttl < 59*time.Minute {
+		t.Errorf("got a ttl of %v, expected the ttl of a new item", ttl)
+	}
+}
+
+func TestExpiryAfterRead(t *testing.T) {
+	for _, policy := range []string{"LRU", "SIEVE"} {
+		expiry := &placeholderExpiry{}
+		tc, _ := New[string, string](Config[string, string]{
+			EvictionPolicy: policy,
+			Expiry:         expiry,
+		})
+
+		tc.Set("kept", "1", time.Hour)
+		tc.Set("extended", "2", time.Second)
+		tc.Set("forever", "3", NoExpiration)
+		_, before, _ := tc.GetWithExpiration("kept")
+
+		tc.Get("kept")
+		tc.Get("extended")
+		tc.Get("forever")
+
+		if _, after, _ := tc.GetWithExpiration("kept"); !after.Equal(before) {
+			t.Errorf("%s: returning the remaining time changed the expiration", policy)
+		}
+		if ttl := ttlOf(tc, "extended"); ttl < 59*time.Second {
+			t.Errorf("%s: got a ttl of %v after a read, expected a minute", policy, ttl)
+		}
+		if len(expiry.reads) != 3 || expiry.reads[2] != NoExpiration {
+			t.Errorf("%s: ExpireAfterRead saw %v, expected NoExpiration for the last read", policy, expiry.reads)
+		}
+	}
+}
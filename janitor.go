@@ -0,0 +1,110 @@
+package swiftcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// janitor periodically sweeps expired items out of the cache in the background.
+// Besides the fixed interval, it wakes up at the earliest pending expiration,
+// so OnEvicted fires close to the moment an item actually expires. It waits
+// at least resolution between two sweeps, so items expiring close together
+// are reaped together instead of spinning.
+type janitor struct {
+	interval   time.Duration // Maximum time between two sweeps
+	resolution time.Duration // Minimum time between two sweeps
+	next       int64         // Earliest known pending expiration in nanoseconds, 0 if none; accessed atomically
+	wake       chan struct{} // Signals that next moved earlier
+	stop       chan struct{} // Closed to ask the janitor to stop
+	done       chan struct{} // Closed once the janitor goroutine has returned
+	once       sync.Once     // Guards closing stop
+}
+
+// newJanitor creates a janitor that sweeps at least every interval, and at
+// most every resolution, or interval if that is shorter.
+func newJanitor(interval, resolution time.Duration) *janitor {
+	return &janitor{
+		interval:   interval,
+		resolution: min(resolution, interval),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// schedule tells the janitor that an item expires at expiration. The janitor
+// is woken up only if that is earlier than the expiration it is waiting for,
+// which keeps the cost on the write path to an atomic load in the common case.
+func (j *janitor) schedule(expiration int64) {
+	if !j.lower(expiration) {
+		return
+	}
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// lower sets next to expiration if that is earlier, and reports whether it did.
+func (j *janitor) lower(expiration int64) bool {
+	for {
+		next := atomic.LoadInt64(&j.next)
+		if next != 0 && next <= expiration {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&j.next, next, expiration) {
+			return true
+		}
+	}
+}
+
+// wait returns how long the janitor should sleep before the next sweep.
+func (j *janitor) wait() time.Duration {
+	wait := j.interval
+	if next := atomic.LoadInt64(&j.next); next > 0 {
+		if until := time.Until(time.Unix(0, next)); until < wait {
+			wait = until
+		}
+	}
+	if wait < j.resolution {
+		wait = j.resolution
+	}
+	return wait
+}
+
+// run calls sweep until the janitor is closed. sweep returns the earliest
+// expiration still pending, or 0 if none. The stop channel is handed to sweep
+// so a long sweep can be abandoned early.
+func (j *janitor) run(sweep func(stop <-chan struct{}) int64) {
+	defer close(j.done)
+
+	timer := time.NewTimer(j.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			// Forget the old deadline; items scheduled during the sweep lower it again.
+			atomic.StoreInt64(&j.next, 0)
+			if next := sweep(j.stop); next > 0 {
+				j.lower(next)
+			}
+		case <-j.wake:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-j.stop:
+			return
+		}
+		timer.Reset(j.wait())
+	}
+}
+
+// close stops the janitor and waits for its goroutine to exit.
+func (j *janitor) close() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+}
@@ -0,0 +1,105 @@
+package swiftcache
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJanitorDeletesExpired(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:    4,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	defer tc.Close()
+
+	var evicted int32
+	tc.OnEvicted(func(k string, v interface{}) {
+		atomic.AddInt32(&evicted, 1)
+	})
+
+	tc.Set("a", 1, 20*time.Millisecond)
+	tc.Set("b", 2, 20*time.Millisecond)
+	tc.Set("c", 3, NoExpiration)
+
+	<-time.After(80 * time.Millisecond)
+	if n := tc.ItemCount(); n != 1 {
+		t.Errorf("Item count is not 1 after the janitor ran: %d", n)
+	}
+	if n := atomic.LoadInt32(&evicted); n != 2 {
+		t.Errorf("OnEvicted was called %d times, expected 2", n)
+	}
+	if _, found := tc.Get("c"); !found {
+		t.Error("Did not find c even though it was set to never expire")
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	tc, _ := NewCache()
+	tc.Set("a", 1, 10*time.Millisecond)
+	tc.Set("b", 2, NoExpiration)
+
+	<-time.After(20 * time.Millisecond)
+	if n := tc.ItemCount(); n != 2 {
+		t.Errorf("Item count is not 2 before DeleteExpired: %d", n)
+	}
+	tc.DeleteExpired()
+	if n := tc.ItemCount(); n != 1 {
+		t.Errorf("Item count is not 1 after DeleteExpired: %d", n)
+	}
+}
+
+func TestCloseStopsJanitor(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{CleanupInterval: time.Millisecond})
+	tc.Close()
+	tc.Close()
+
+	select {
+	case <-tc.janitor.done:
+	default:
+		t.Fatal("janitor goroutine is still running after Close")
+	}
+
+	// Close on a cache without a janitor is a no-op
+	tc2, _ := NewCache()
+	tc2.Close()
+}
+
+func TestJanitorOnEvictedConcurrently(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:    4,
+		CleanupInterval: time.Millisecond,
+	})
+	defer tc.Close()
+
+	for i := 0; i < 1000; i++ {
+		tc.Set(strconv.Itoa(i), i, time.Duration(i%20)*time.Millisecond+time.Millisecond)
+	}
+
+	// Replacing the callback races with the janitor evicting items
+	var evicted int32
+	deadline := time.Now().Add(30 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		tc.OnEvicted(func(k string, v interface{}) {
+			atomic.AddInt32(&evicted, 1)
+		})
+	}
+	<-time.After(10 * time.Millisecond)
+	if atomic.LoadInt32(&evicted) == 0 {
+		t.Error("OnEvicted was never called by the janitor")
+	}
+}
+
+func TestJanitorResolution(t *testing.T) {
+	j := newJanitor(time.Minute, 20*time.Millisecond)
+	j.lower(time.Now().Add(time.Millisecond).UnixNano())
+	if wait := j.wait(); wait != 20*time.Millisecond {
+		t.Errorf("got a wait of %v for an imminent expiration, expected the resolution", wait)
+	}
+
+	j = newJanitor(5*time.Millisecond, time.Second)
+	if wait := j.wait(); wait != 5*time.Millisecond {
+		t.Errorf("got a wait of %v, expected the resolution to be capped at the interval", wait)
+	}
+}
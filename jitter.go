@@ -0,0 +1,28 @@
+package swiftcache
+
+import "time"
+
+// Jitter randomly shortens ttls, so items written together with the same ttl
+// do not all expire at the same instant. Each ttl is shortened by a uniformly
+// random amount of up to Fraction of the ttl, or up to Range, whichever is
+// larger, but always stays positive.
+type Jitter struct {
+	Fraction float64       // Maximum share of the ttl to remove, e.g. 0.1 for up to 10%.
+	Range    time.Duration // Maximum duration to remove.
+}
+
+// jitter returns ttl, which must be positive, shortened by the configured TTLJitter.
+func (c *Cache[K, V]) jitter(ttl time.Duration) time.Duration {
+	spread := time.Duration(float64(ttl) * c.ttlJitter.Fraction)
+	if c.ttlJitter.Range > spread {
+		spread = c.ttlJitter.Range
+	}
+	if spread <= 0 {
+		return ttl
+	}
+	if spread > ttl {
+		spread = ttl
+	}
+	// random is below 1, so at least some of the ttl is left
+	return ttl - time.Duration(float64(spread)*c.random())
+}
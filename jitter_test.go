@@ -0,0 +1,87 @@
+package swiftcache
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestTTLJitterFraction(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		TTLJitter: Jitter{Fraction: 0.2},
+		Random:    func() float64 { return 0.5 },
+	})
+
+	before := time.Now()
+	tc.Set("a", 1, time.Hour)
+	_, expiration, _ := tc.GetWithExpiration("a")
+
+	// Half of the maximum 12 minutes is taken off the ttl
+	if ttl := expiration.Sub(before); ttl < 54*time.Minute || ttl > 54*time.Minute+time.Second {
+		t.Errorf("got a ttl of %v, expected 54m", ttl)
+	}
+
+	tc.Set("b", 2, NoExpiration)
+	if _, expiration, _ := tc.GetWithExpiration("b"); !expiration.IsZero() {
+		t.Error("jitter gave an expiration to an item that never expires")
+	}
+}
+
+func TestTTLJitterRange(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		DefaultExpiration: time.Hour,
+		TTLJitter:         Jitter{Fraction: 0.01, Range: 10 * time.Minute},
+		Random:            func() float64 { return 0.5 },
+	})
+
+	before := time.Now()
+	tc.Set("a", 1, DefaultExpiration)
+	_, expiration, _ := tc.GetWithExpiration("a")
+
+	// The range is larger than 1% of the ttl, so up to 10 minutes are taken off
+	if ttl := expiration.Sub(before); ttl < 55*time.Minute || ttl > 55*time.Minute+time.Second {
+		t.Errorf("got a ttl of %v, expected 55m", ttl)
+	}
+}
+
+func TestTTLJitterStaysPositive(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		TTLJitter: Jitter{Range: time.Hour},
+		Random:    func() float64 { return 0.999999 },
+	})
+
+	tc.Set("a", 1, time.Second)
+	if item, found := tc.Item("a"); !found || item.Expiration == 0 {
+		t.Fatal("jitter larger than the ttl turned it into no expiration")
+	}
+	<-time.After(time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("jitter larger than the ttl did not shorten it")
+	}
+}
+
+func TestTTLJitterSeeded(t *testing.T) {
+	newCache := func() *Cache[string, int] {
+		tc, _ := New[string, int](Config[string, int]{
+			TTLJitter: Jitter{Fraction: 0.5},
+			Random:    rand.New(rand.NewSource(42)).Float64,
+		})
+		return tc
+	}
+
+	a, b := newCache(), newCache()
+	distinct := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		ttl := a.jitter(time.Hour)
+		if ttl != b.jitter(time.Hour) {
+			t.Fatal("caches with the same seed jittered differently")
+		}
+		if ttl <= 30*time.Minute || ttl > time.Hour {
+			t.Fatalf("jittered ttl %v outside of (30m, 1h]", ttl)
+		}
+		distinct[ttl] = true
+	}
+	if len(distinct) < 90 {
+		t.Errorf("only %d distinct ttls out of 100", len(distinct))
+	}
+}
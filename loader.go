@@ -0,0 +1,172 @@
+package swiftcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// errGoexit is returned to waiting callers when a loader calls runtime.Goexit.
+var errGoexit = errors.New("loader called runtime.Goexit")
+
+// PanicError is what GetOrLoad panics with when the loader panicked, so every
+// caller waiting for the same key sees the panic.
+type PanicError struct {
+	Value any    // The value the loader panicked with
+	Stack []byte // Stack trace of the loader's goroutine at the time of the panic
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("loader panicked: %v\n\n%s", p.Value, p.Stack)
+}
+
+// LoaderFunc loads the value of key for a cache, along with the ttl to store
+// it with, see Config.Loader.
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, time.Duration, error)
+
+// loadCall is a load in flight for a single key.
+type loadCall[V any] struct {
+	done     chan struct{}      // Closed once value, err and panicked are set
+	value    V                  // Loaded value
+	err      error              // Error returned by the loader
+	panic    *PanicError        // Set if the loader panicked
+	waiters  int                // Callers still waiting, guarded by loadGroup.lock
+	detached bool               // Background refresh that keeps running without waiters
+	cancel   context.CancelFunc // Cancels the loader's context
+}
+
+// loadGroup coalesces concurrent loads of the same key, like singleflight.
+type loadGroup[K comparable, V any] struct {
+	lock  sync.Mutex
+	calls map[K]*loadCall[V]
+}
+
+// GetOrLoad returns the value for key if it is cached. Otherwise it calls
+// loader, stores the value it returns with the returned ttl, and returns it.
+// Concurrent calls for the same key share a single loader call and all wait
+// for its result. A value that is stale or expiring early is returned right
+// away and reloaded with loader in the background, see Config.StaleTTL and
+// Config.XFetchBeta.
+//
+// If ctx is done before the value is loaded, GetOrLoad returns ctx.Err().
+// The loader runs on its own goroutine with a context that carries the values
+// of ctx and is canceled once every caller waiting for it has given up. If the
+// loader panics, every waiting caller panics with a *PanicError.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, time.Duration, error)) (V, error) {
+	segment := c.getSegment(key)
+	if value, _, found := c.lookup(segment, key, loader); found {
+		return value, nil
+	}
+
+	group := &segment.loads
+	group.lock.Lock()
+	call, loading := group.calls[key]
+	if !loading {
+		// The value may have been stored by a load that finished meanwhile
+		if item, found := segment.get(key); found {
+			group.lock.Unlock()
+			return item.Value, nil
+		}
+
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &loadCall[V]{done: make(chan struct{}), cancel: cancel}
+		if group.calls == nil {
+			group.calls = make(map[K]*loadCall[V])
+		}
+		group.calls[key] = call
+		go c.load(loadCtx, key, group, call, loader)
+	}
+	call.waiters++
+	group.lock.Unlock()
+
+	select {
+	case <-call.done:
+		if call.panic != nil {
+			panic(call.panic)
+		}
+		return call.value, call.err
+	case <-ctx.Done():
+		group.lock.Lock()
+		call.waiters--
+		if call.waiters == 0 && !call.detached {
+			// Nobody is interested anymore: stop the loader and let the next caller start afresh
+			call.cancel()
+			if group.calls[key] == call {
+				delete(group.calls, key)
+			}
+		}
+		group.lock.Unlock()
+
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs loader for key, stores its value through the normal Set path,
+// and hands the result to everyone waiting on call.
+func (c *Cache[K, V]) load(ctx context.Context, key K, group *loadGroup[K, V], call *loadCall[V], loader func(ctx context.Context) (V, time.Duration, error)) {
+	returned := false
+	defer func() {
+		if r := recover(); r != nil {
+			call.panic = &PanicError{Value: r, Stack: debug.Stack()}
+		} else if !returned {
+			call.err = errGoexit
+		}
+
+		group.lock.Lock()
+		if group.calls[key] == call {
+			delete(group.calls, key)
+		}
+		if call.panic != nil && call.detached && call.waiters == 0 {
+			// Nobody is left to panic, so a failed refresh must not go unnoticed
+			log.Printf("Refresh of key %v panicked: %v\n\n%s", key, call.panic.Value, call.panic.Stack)
+		}
+		group.lock.Unlock()
+
+		call.cancel()
+		close(call.done)
+	}()
+
+	start := time.Now()
+	value, ttl, err := loader(ctx)
+	if err == nil {
+		// A value too large to cache is still handed to the callers
+		options := c.writeOptions(ttl)
+		options.delta = time.Since(start)
+		_, _, _ = c.set(c.getSegment(key), key, value, options)
+	}
+	call.value, call.err = value, err
+	returned = true
+}
+
+// refresh reloads key in the background with loader, unless a load of key is
+// already in flight. The current value keeps being served until the new one is
+// stored; if loader fails, the current value is kept until it expires.
+func (c *Cache[K, V]) refresh(segment *Segment[K, V], key K, loader func(ctx context.Context) (V, time.Duration, error)) {
+	group := &segment.loads
+	group.lock.Lock()
+	defer group.lock.Unlock()
+
+	if _, loading := group.calls[key]; loading {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	call := &loadCall[V]{done: make(chan struct{}), detached: true, cancel: cancel}
+	if group.calls == nil {
+		group.calls = make(map[K]*loadCall[V])
+	}
+	group.calls[key] = call
+	go c.load(ctx, key, group, call, loader)
+}
+
+// loaderFor binds the configured Loader to key.
+func (c *Cache[K, V]) loaderFor(key K) func(ctx context.Context) (V, time.Duration, error) {
+	return func(ctx context.Context) (V, time.Duration, error) {
+		return c.loader(ctx, key)
+	}
+}
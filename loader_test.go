@@ -0,0 +1,441 @@
+package swiftcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCoalesces(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	var calls int32
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-time.After(50 * time.Millisecond)
+		return 42, NoExpiration, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := tc.GetOrLoad(context.Background(), "answer", loader)
+			if err != nil || v != 42 {
+				t.Errorf("GetOrLoad returned %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("loader was called %d times, expected once", n)
+	}
+	if v, found := tc.Get("answer"); !found || v != 42 {
+		t.Error("the loaded value was not stored in the cache")
+	}
+
+	// Cached values are returned without calling the loader
+	tc.GetOrLoad(context.Background(), "answer", loader)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("loader was called %d times for a cached key", n)
+	}
+}
+
+func TestGetOrLoadStoresTTL(t *testing.T) {
+	tc, _ := New[string, int]()
+	tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+		return 1, time.Minute, nil
+	})
+	if _, expiration, found := tc.GetWithExpiration("a"); !found || expiration.IsZero() {
+		t.Error("the loaded value was not stored with the returned ttl")
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	tc, _ := New[string, int]()
+	errLoad := errors.New("database is down")
+
+	_, err := tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+		return 0, NoExpiration, errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Errorf("GetOrLoad returned %v, expected the loader's error", err)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("a failed load stored a value")
+	}
+
+	v, err := tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+		return 1, NoExpiration, nil
+	})
+	if err != nil || v != 1 {
+		t.Errorf("GetOrLoad after a failed load returned %v, %v", v, err)
+	}
+}
+
+func TestGetOrLoadCancel(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	loaderCanceled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := tc.GetOrLoad(ctx, "a", func(ctx context.Context) (int, time.Duration, error) {
+		<-ctx.Done()
+		close(loaderCanceled)
+		return 0, NoExpiration, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetOrLoad returned %v, expected context.Canceled", err)
+	}
+
+	select {
+	case <-loaderCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("the loader's context was not canceled after its only caller gave up")
+	}
+}
+
+func TestGetOrLoadCancelOneOfMany(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		select {
+		case <-release:
+			return 1, NoExpiration, nil
+		case <-ctx.Done():
+			return 0, NoExpiration, ctx.Err()
+		}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := tc.GetOrLoad(context.Background(), "a", loader)
+		result <- err
+	}()
+	<-time.After(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tc.GetOrLoad(ctx, "a", loader); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetOrLoad returned %v, expected context.DeadlineExceeded", err)
+	}
+
+	// The first caller is still waiting, so the load goes on
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("the remaining caller got %v", err)
+	}
+}
+
+func TestGetOrLoadPanic(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				r := recover()
+				if pe, ok := r.(*PanicError); !ok || pe.Value != "boom" {
+					t.Errorf("GetOrLoad panicked with %v, expected a *PanicError for boom", r)
+				}
+			}()
+			tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+				<-time.After(20 * time.Millisecond)
+				panic("boom")
+			})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoaderOnGet(t *testing.T) {
+	var calls int32
+	tc, _ := New[int, string](Config[int, string]{
+		Loader: func(ctx context.Context, key int) (string, time.Duration, error) {
+			atomic.AddInt32(&calls, 1)
+			if key < 0 {
+				return "", NoExpiration, errors.New("negative key")
+			}
+			return fmt.Sprint("value ", key), time.Minute, nil
+		},
+	})
+
+	if v, found := tc.Get(1); !found || v != "value 1" {
+		t.Errorf("Get returned %q, %v, expected the loaded value", v, found)
+	}
+	if _, expiration, _ := tc.GetWithExpiration(1); expiration.IsZero() {
+		t.Error("the loaded value was not stored with the loader's ttl")
+	}
+	tc.Get(1)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("loader was called %d times, expected once", n)
+	}
+
+	if _, found := tc.Get(-1); found {
+		t.Error("Get reported a hit for a key the loader failed to load")
+	}
+	if _, err := tc.GetOrLoad(context.Background(), -1, func(ctx context.Context) (string, time.Duration, error) {
+		return "", NoExpiration, errors.New("negative key")
+	}); err == nil {
+		t.Error("GetOrLoad did not return the loader's error")
+	}
+}
+
+func TestRefreshAfter(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	tc, _ := New[string, int](Config[string, int]{
+		RefreshAfter: 20 * time.Millisecond,
+		Loader: func(ctx context.Context, key string) (int, time.Duration, error) {
+			<-release
+			return int(atomic.AddInt32(&calls, 1)) + 1, NoExpiration, nil
+		},
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	if v, _ := tc.Get("a"); v != 1 {
+		t.Fatalf("got %d, expected the value that was set", v)
+	}
+
+	<-time.After(30 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		// The old value is served without waiting while the refresh is pending
+		if v, found := tc.Get("a"); !found || v != 1 {
+			t.Fatalf("got %d, %v while refreshing, expected the current value", v, found)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if v, _ := tc.Get("a"); v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the refreshed value was never stored")
+		}
+		<-time.After(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("loader was called %d times, expected a single refresh", n)
+	}
+}
+
+func TestRefreshAfterErrorKeepsValue(t *testing.T) {
+	refreshed := make(chan struct{}, 1)
+	tc, _ := New[string, int](Config[string, int]{
+		RefreshAfter: 10 * time.Millisecond,
+		Loader: func(ctx context.Context, key string) (int, time.Duration, error) {
+			defer func() { refreshed <- struct{}{} }()
+			return 0, NoExpiration, errors.New("backend unavailable")
+		},
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	<-time.After(20 * time.Millisecond)
+	tc.Get("a")
+	<-refreshed
+
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Errorf("got %d, %v after a failed refresh, expected the old value", v, found)
+	}
+}
+
+func TestRefreshAfterRequiresLoader(t *testing.T) {
+	if _, err := New[string, int](Config[string, int]{RefreshAfter: time.Second}); err == nil {
+		t.Error("expected an error for RefreshAfter without a Loader")
+	}
+}
+
+func TestStaleTTL(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{StaleTTL: 100 * time.Millisecond})
+
+	tc.Set("a", 1, 20*time.Millisecond)
+	tc.Set("b", 2, NoExpiration)
+	if _, stale, found := tc.GetWithStale("a"); !found || stale {
+		t.Errorf("fresh item: stale %v, found %v", stale, found)
+	}
+
+	<-time.After(30 * time.Millisecond)
+	if v, stale, found := tc.GetWithStale("a"); !found || !stale || v != 1 {
+		t.Errorf("item past its ttl: got %d, stale %v, found %v; expected a stale hit", v, stale, found)
+	}
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Error("Get did not serve the stale value")
+	}
+	if item, _ := tc.Item("a"); !item.Stale() || item.Expired() {
+		t.Error("Item does not report the item as stale")
+	}
+	if _, stale, _ := tc.GetWithStale("b"); stale {
+		t.Error("an item without expiration became stale")
+	}
+
+	<-time.After(100 * time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("the stale item was still served after StaleTTL")
+	}
+}
+
+func TestSetWithStale(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.SetWithStale("a", 1, 10*time.Millisecond, time.Minute)
+	tc.Set("b", 2, 10*time.Millisecond)
+	<-time.After(20 * time.Millisecond)
+
+	if _, stale, found := tc.GetWithStale("a"); !found || !stale {
+		t.Errorf("got stale %v, found %v; expected a stale hit", stale, found)
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("an item set without a stale window outlived its ttl")
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	release := make(chan struct{})
+	tc, _ := New[string, int](Config[string, int]{
+		StaleTTL: time.Minute,
+		Loader: func(ctx context.Context, key string) (int, time.Duration, error) {
+			<-release
+			return 2, time.Minute, nil
+		},
+	})
+
+	tc.Set("a", 1, 10*time.Millisecond)
+	<-time.After(20 * time.Millisecond)
+
+	if v, stale, _ := tc.GetWithStale("a"); v != 1 || !stale {
+		t.Fatalf("got %d, stale %v; expected the stale value while revalidating", v, stale)
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, stale, _ := tc.GetWithStale("a")
+		if v == 2 && !stale {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the stale value was never revalidated")
+		}
+		<-time.After(time.Millisecond)
+	}
+}
+
+func TestStaleIfError(t *testing.T) {
+	var calls int32
+	tc, _ := New[string, int](Config[string, int]{
+		StaleTTL: 50 * time.Millisecond,
+		Loader: func(ctx context.Context, key string) (int, time.Duration, error) {
+			atomic.AddInt32(&calls, 1)
+			return 0, NoExpiration, errors.New("backend unavailable")
+		},
+	})
+
+	tc.Set("a", 1, 10*time.Millisecond)
+	<-time.After(20 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		if v, stale, found := tc.GetWithStale("a"); !found || !stale || v != 1 {
+			t.Fatalf("got %d, stale %v, found %v; expected the stale value while the loader fails", v, stale, found)
+		}
+		<-time.After(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&calls) == 0 {
+		t.Error("the stale value was never revalidated")
+	}
+
+	<-time.After(50 * time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("the stale value was served past StaleTTL")
+	}
+}
+
+func TestXFetchEarlyExpiration(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{XFetchBeta: 1})
+
+	tc.SetWithDelta("slow", 1, time.Hour, 10*time.Minute)
+	tc.SetWithDelta("fast", 2, time.Hour, time.Millisecond)
+	tc.Set("unknown", 3, time.Hour)
+	tc.SetWithDelta("forever", 4, NoExpiration, 10*time.Minute)
+
+	// The least likely draw never expires anything early
+	tc.random = func() float64 { return 0 }
+	for _, key := range []string{"slow", "fast", "unknown", "forever"} {
+		if _, found := tc.Get(key); !found {
+			t.Errorf("%s expired early on the least likely draw", key)
+		}
+	}
+
+	// On an unlikely draw, only a value that is slow to compute expires an hour early
+	tc.random = func() float64 { return 1 - 1e-9 }
+	if _, found := tc.Get("slow"); found {
+		t.Error("a slow to compute value did not expire early")
+	}
+	if _, found := tc.Get("fast"); !found {
+		t.Error("a fast to compute value expired an hour early")
+	}
+	if _, found := tc.Get("unknown"); !found {
+		t.Error("a value without recorded compute time expired early")
+	}
+	if _, found := tc.Get("forever"); !found {
+		t.Error("a value without expiration expired early")
+	}
+
+	// Early expiration only affects the reader that drew it
+	tc.random = func() float64 { return 0 }
+	if _, found := tc.Get("slow"); !found {
+		t.Error("early expiration removed the item")
+	}
+}
+
+func TestXFetchWithLoader(t *testing.T) {
+	var calls int32
+	tc, _ := New[string, int](Config[string, int]{
+		XFetchBeta: 1,
+		Loader: func(ctx context.Context, key string) (int, time.Duration, error) {
+			n := atomic.AddInt32(&calls, 1)
+			<-time.After(10 * time.Millisecond)
+			return int(n), time.Hour, nil
+		},
+	})
+	tc.random = func() float64 { return 0 }
+
+	if v, _ := tc.Get("a"); v != 1 {
+		t.Fatalf("got %d, expected the loaded value", v)
+	}
+	if item, _ := tc.Item("a"); item.delta < int64(10*time.Millisecond) {
+		t.Errorf("recorded compute time %v, expected at least the loader's duration", time.Duration(item.delta))
+	}
+
+	// Expiring early serves the current value and reloads it in the background
+	tc.random = func() float64 { return 1 }
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Errorf("got %d, %v; expected the current value while reloading", v, found)
+	}
+	tc.random = func() float64 { return 0 }
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if v, _ := tc.Get("a"); v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the value expiring early was never reloaded")
+		}
+		<-time.After(time.Millisecond)
+	}
+}
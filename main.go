@@ -1,86 +1,181 @@
 package swiftcache
 
 import (
-	"container/list"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"io"
 	"log"
+	"math"
+	"math/rand"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// CacheConfig is used to configure a cache instance.
-type CacheConfig struct {
-	SegmentCount      int                // Number of segments to reduce lock contention
-	MaxCacheSize      int                // Maximum size for each cache segment
-	DefaultExpiration time.Duration      // Default expiration time for cache items
-	HashFunc          func() hash.Hash32 // Hash function to distribute keys across segments.
-	EvictionPolicy    string             // Eviction policy: "LRU" or "FIFO".
+// Config is used to configure a Cache[K, V] instance.
+type Config[K comparable, V any] struct {
+	SegmentCount      int                                  // Number of segments to reduce lock contention
+	MaxCacheSize      int                                  // Maximum size for each cache segment
+	DefaultExpiration time.Duration                        // Expiration for items set with DefaultExpiration; 0 or NoExpiration means never expire
+	HashFunc          func() hash.Hash32                   // Hash function to distribute keys across segments.
+	EvictionPolicy    string                               // Eviction policy: "LRU", "FIFO", "LFU", "TinyLFU", "ARC", "SIEVE" or "S3-FIFO".
+	NewEvictionPolicy func(capacity int) EvictionPolicy[K] // Creates a custom eviction policy per segment; overrides EvictionPolicy.
+	CleanupInterval   time.Duration                        // Maximum time between janitor sweeps of expired items; 0 disables the janitor.
+	CleanupResolution time.Duration                        // Minimum time between janitor sweeps triggered by expirations; defaults to DefaultCleanupResolution.
+	MaxBytes          int64                                // Maximum total weight for each cache segment; 0 disables the limit.
+	Weigher           func(key K, value V) int64           // Computes the weight of an item; defaults to Sizer, or 1.
+	MaxItems          int                                  // Maximum number of items in the whole cache, shared by all segments; overrides MaxCacheSize.
+	MaxTotalBytes     int64                                // Maximum total weight of the whole cache, shared by all segments; overrides MaxBytes.
+	Loader            LoaderFunc[K, V]                     // Loads missing keys on Get; nil disables loading.
+	RefreshAfter      time.Duration                        // Age after which Get reloads an item in the background; requires Loader.
+	StaleTTL          time.Duration                        // Time items are still served, flagged as stale, after their ttl; 0 disables.
+	XFetchBeta        float64                              // Enables probabilistic early expiration; higher values expire earlier, 1 is typical.
+	TTLJitter         Jitter                               // Randomly shortens ttls so items set together expire at different times.
+	Random            func() float64                       // Returns random numbers in [0, 1) for TTLJitter and XFetchBeta; must be safe for concurrent use.
+	SlidingExpiration bool                                 // Extends an item's expiration by its ttl on every hit, up to MaxLifetime.
+	MaxLifetime       time.Duration                        // Maximum time a sliding item lives after it was written, however often it is read; 0 means no limit.
+	Expiry            Expiry[K, V]                         // Computes the ttl of each item when it is created, updated and read.
+	Overflow          OverflowMode                         // What increments and decrements do when a result does not fit the value's type.
 }
 
+// CacheConfig is used to configure a cache instance created by NewCache.
+type CacheConfig = Config[string, any]
+
 const (
-	DefaultExpiration     time.Duration = 0     // Default expiration time
-	NoExpiration          time.Duration = -1    // For use with functions that take an expiration time.
+	DefaultExpiration     time.Duration = 0     // Use the expiration configured in CacheConfig.DefaultExpiration.
+	NoExpiration          time.Duration = -1    // Never expire; for use with functions that take an expiration time.
+	KeepTTL               time.Duration = -2    // Keep the expiration of an existing item; new items never expire.
 	DefaultSegmentCount                 = 512   // Default number of segments to reduce lock contention
 	MaxCacheSize                        = 1000  // Default maximum size for each cache segment
 	DefaultEvictionPolicy               = "LRU" // Default eviction policy: "LRU".
+
+	DefaultCleanupResolution = time.Millisecond // Default minimum time between janitor sweeps triggered by expirations.
+
+	sweepBatchSize = 128 // Maximum number of expired items removed per segment lock
 )
 
 // Item defines an item in the cache
-type Item struct {
-	Value      interface{}   // Value of the cache item
-	Expiration int64         // Expiration time in nanoseconds
-	node       *list.Element // Used for LRU to point to the node in the list.
+type Item[V any] struct {
+	Value      V      // Value of the cache item
+	Expiration int64  // Expiration time in nanoseconds
+	heapIndex  int    // Position in the segment's expiration heap, -1 if the item never expires.
+	weight     int64  // Weight of the item, counted against the segment's maxWeight.
+	written    int64  // Time the value was last written in nanoseconds, see Config.RefreshAfter.
+	stale      int64  // Time after which the value is stale in nanoseconds, 0 if it never is.
+	delta      int64  // Time it took to compute the value in nanoseconds, see Config.XFetchBeta.
+	idle       int64  // Time a sliding item lives after its last hit in nanoseconds, 0 if it does not slide.
+	deadline   int64  // Time a sliding item expires at the latest in nanoseconds, 0 if there is no limit.
+	version    uint64 // Changes whenever the value is written, see Cache.CompareAndSwap.
 }
 
 // Expired checks if the cache item is expired
-func (item *Item) Expired() bool {
+func (item *Item[V]) Expired() bool {
 	return item.Expiration != 0 && time.Now().UnixNano() > item.Expiration
 }
 
+// Stale checks if the cache item is past its ttl but still served until it
+// expires, see Config.StaleTTL.
+func (item *Item[V]) Stale() bool {
+	return item.staleAt(time.Now().UnixNano())
+}
+
+// staleAt reports whether the cache item is stale at now.
+func (item *Item[V]) staleAt(now int64) bool {
+	return item.stale != 0 && now > item.stale
+}
+
 // Segment represents a segment of the cache
-type Segment struct {
-	items   map[string]*Item // Map to store cache items
-	queue   *list.List       // Used for both FIFO and LRU. The usage depends on the eviction policy.
-	lock    sync.RWMutex     // Read/Write lock for concurrent access
-	size    int              // Current size of the cache segment
-	maxSize int              // Max size of the cache segment
-	cache   *Cache           // Reference to the parent Cache.
+type Segment[K comparable, V any] struct {
+	items            map[K]*Item[V]       // Map to store cache items
+	policy           EvictionPolicy[K]    // Decides which key to evict when the segment is full.
+	concurrentAccess bool                 // Whether hits only need the read lock, see ConcurrentAccessPolicy.
+	expirations      expirationHeap[K, V] // Items that expire, ordered by expiration time.
+	nextExpiration   atomic.Int64         // Earliest expiration in expirations, 0 if none; lets sweeps skip the segment without locking it.
+	lock             sync.RWMutex         // Read/Write lock for concurrent access
+	size             int                  // Current size of the cache segment
+	maxSize          int                  // Max size of the cache segment
+	weight           int64                // Current total weight of the cache segment
+	maxWeight        int64                // Max total weight of the cache segment, 0 if unlimited
+	loads            loadGroup[K, V]      // Loads in flight for keys of this segment.
+	policyCapacity   int                  // Capacity the eviction policy is currently sized for.
+	used             atomic.Uint64        // The shared budget's clock when the segment was last read or written.
+	cache            *Cache[K, V]         // Reference to the parent Cache.
 }
 
 // newSegment creates a new cache segment
-func newSegment(maxSize int, cache *Cache) *Segment {
-	return &Segment{
-		items:   make(map[string]*Item),
-		queue:   list.New(),
-		size:    0,
-		maxSize: maxSize,
-		cache:   cache,
-	}
-}
-
-// Cache is a structure holding multiple segments
-type Cache struct {
-	segments          []*Segment                // Slice of cache segments
-	segmentCount      int                       // Number of segments
-	maxCacheSize      int                       // Maximum size per segment
-	defaultExpiration time.Duration             // Default expiration time for segment items
-	hashFunc          func() hash.Hash32        // Hash function to distribute keys across segments.
-	onEvicted         func(string, interface{}) // Optional callback for evicted items.
-	evictionPolicy    string                    // Store the eviction policy here.
+func newSegment[K comparable, V any](maxSize int, maxWeight int64, cache *Cache[K, V]) *Segment[K, V] {
+	s := &Segment[K, V]{
+		items:     make(map[K]*Item[V]),
+		size:      0,
+		maxSize:   maxSize,
+		maxWeight: maxWeight,
+		cache:     cache,
+	}
+	s.resetPolicy()
+	// Decided once: get reads the flag before taking any lock, so clear must
+	// not rewrite it. Every policy of a segment comes from the same factory.
+	if p, ok := s.policy.(ConcurrentAccessPolicy); ok {
+		s.concurrentAccess = p.ConcurrentAccess()
+	}
+	return s
+}
+
+// resetPolicy replaces the segment's eviction policy with a fresh one.
+func (s *Segment[K, V]) resetPolicy() {
+	s.policyCapacity = s.cache.policyCapacity
+	s.policy = s.cache.newPolicy(s.policyCapacity)
+}
+
+// Cache is a structure holding multiple segments.
+// Keys of type K are distributed across segments and map to values of type V.
+type Cache[K comparable, V any] struct {
+	segments          []*Segment[K, V]   // Slice of cache segments
+	segmentCount      int                // Number of segments
+	maxCacheSize      int                // Maximum size per segment
+	maxBytes          int64              // Maximum weight per segment, 0 if unlimited
+	budget            *budget            // Optional capacity shared by all segments
+	policyCapacity    int                // Capacity each segment's eviction policy is sized for
+	weigher           func(K, V) int64   // Optional function computing item weights
+	defaultExpiration time.Duration      // Default expiration time for segment items
+	hashFunc          func() hash.Hash32 // Hash function to distribute keys across segments.
+	newPolicy         policyFactory[K]   // Creates the eviction policy of each segment.
+	janitor           *janitor           // Optional background sweeper for expired items.
+	loader            LoaderFunc[K, V]   // Optional loader for missing keys
+	refreshAfter      time.Duration      // Age after which items are reloaded, 0 if never
+	staleTTL          time.Duration      // Time items are served stale after their ttl
+	xfetchBeta        float64            // Scales early expiration, 0 if disabled
+	ttlJitter         Jitter             // Random shortening of ttls
+	random            func() float64     // Source of random numbers in [0, 1)
+	sliding           bool               // Whether items expire after their last hit rather than their write
+	maxLifetime       time.Duration      // Maximum lifetime of sliding items, 0 if unlimited
+	expiry            Expiry[K, V]       // Optional per-item ttl computation
+	versions          atomic.Uint64      // Last version given to a written value
+	overflow          OverflowMode       // Default overflow handling of counters
 	lock              sync.RWMutex
+
+	onEvicted atomic.Pointer[func(K, V)] // Optional callback for evicted items, read without locking
+}
+
+// NewCache creates a new cache instance holding string keys and values of any type.
+// It is kept for compatibility; New creates caches with other key and value types.
+func NewCache(options ...CacheConfig) (*Cache[string, any], error) {
+	return New[string, any](options...)
 }
 
-// NewCache creates a new cache instance
-func NewCache(options ...CacheConfig) (*Cache, error) {
-	config := CacheConfig{
+// New creates a new type-safe cache instance
+func New[K comparable, V any](options ...Config[K, V]) (*Cache[K, V], error) {
+	config := Config[K, V]{
 		SegmentCount:      DefaultSegmentCount, // Number of segments to reduce lock contention
 		MaxCacheSize:      MaxCacheSize,        // Maximum size for each cache segment
 		DefaultExpiration: DefaultExpiration,
 		HashFunc:          fnv.New32,
 		EvictionPolicy:    DefaultEvictionPolicy,
+		CleanupResolution: DefaultCleanupResolution,
 	}
 
 	if len(options) > 0 {
@@ -91,15 +186,50 @@ func NewCache(options ...CacheConfig) (*Cache, error) {
 		if userConfig.MaxCacheSize > 0 {
 			config.MaxCacheSize = userConfig.MaxCacheSize
 		}
-		if userConfig.DefaultExpiration >= 0 {
-			config.DefaultExpiration = userConfig.DefaultExpiration
-		}
+		config.DefaultExpiration = userConfig.DefaultExpiration
 		if userConfig.HashFunc != nil {
 			config.HashFunc = userConfig.HashFunc
 		}
 		if userConfig.EvictionPolicy != "" {
 			config.EvictionPolicy = userConfig.EvictionPolicy
 		}
+		config.NewEvictionPolicy = userConfig.NewEvictionPolicy
+		if userConfig.MaxBytes > 0 {
+			config.MaxBytes = userConfig.MaxBytes
+		}
+		config.Weigher = userConfig.Weigher
+		if userConfig.MaxItems > 0 {
+			config.MaxItems = userConfig.MaxItems
+		}
+		if userConfig.MaxTotalBytes > 0 {
+			config.MaxTotalBytes = userConfig.MaxTotalBytes
+		}
+		if userConfig.CleanupInterval > 0 {
+			config.CleanupInterval = userConfig.CleanupInterval
+		}
+		if userConfig.CleanupResolution > 0 {
+			config.CleanupResolution = userConfig.CleanupResolution
+		}
+		config.Loader = userConfig.Loader
+		if userConfig.RefreshAfter > 0 {
+			config.RefreshAfter = userConfig.RefreshAfter
+		}
+		if userConfig.StaleTTL > 0 {
+			config.StaleTTL = userConfig.StaleTTL
+		}
+		if userConfig.XFetchBeta > 0 {
+			config.XFetchBeta = userConfig.XFetchBeta
+		}
+		config.TTLJitter = userConfig.TTLJitter
+		if userConfig.Random != nil {
+			config.Random = userConfig.Random
+		}
+		config.SlidingExpiration = userConfig.SlidingExpiration
+		if userConfig.MaxLifetime > 0 {
+			config.MaxLifetime = userConfig.MaxLifetime
+		}
+		config.Expiry = userConfig.Expiry
+		config.Overflow = userConfig.Overflow
 	}
 
 	// Validate and set defaults for config
@@ -112,155 +242,438 @@ func NewCache(options ...CacheConfig) (*Cache, error) {
 	if config.HashFunc == nil {
 		config.HashFunc = fnv.New32
 	}
+	if config.Random == nil {
+		config.Random = rand.Float64
+	}
 
-	if config.DefaultExpiration < -1 {
-		config.DefaultExpiration = DefaultExpiration
+	// A zero or negative default means items set with DefaultExpiration never expire
+	if config.DefaultExpiration <= 0 {
+		config.DefaultExpiration = NoExpiration
 	}
 
 	if config.SegmentCount&(config.SegmentCount-1) != 0 {
 		return nil, fmt.Errorf("cache segment count must be a power of 2")
 	}
+	if config.RefreshAfter > 0 && config.Loader == nil {
+		return nil, fmt.Errorf("cache refresh requires a loader")
+	}
+
+	newPolicy := policyFactory[K](config.NewEvictionPolicy)
+	if newPolicy == nil {
+		var err error
+		if newPolicy, err = newPolicyFactory[K](config.EvictionPolicy); err != nil {
+			return nil, err
+		}
+	}
 
-	c := &Cache{
-		segments:          make([]*Segment, config.SegmentCount),
+	c := &Cache[K, V]{
+		segments:          make([]*Segment[K, V], config.SegmentCount),
 		segmentCount:      config.SegmentCount,
 		maxCacheSize:      config.MaxCacheSize,
+		maxBytes:          config.MaxBytes,
+		weigher:           config.Weigher,
 		defaultExpiration: config.DefaultExpiration,
 		hashFunc:          config.HashFunc,
-		evictionPolicy:    config.EvictionPolicy,
+		newPolicy:         newPolicy,
+		policyCapacity:    config.MaxCacheSize,
+		loader:            config.Loader,
+		refreshAfter:      config.RefreshAfter,
+		staleTTL:          config.StaleTTL,
+		xfetchBeta:        config.XFetchBeta,
+		ttlJitter:         config.TTLJitter,
+		random:            config.Random,
+		sliding:           config.SlidingExpiration,
+		maxLifetime:       config.MaxLifetime,
+		expiry:            config.Expiry,
+		overflow:          config.Overflow,
+	}
+
+	// A cache-wide limit replaces the matching per-segment limit
+	if config.MaxItems > 0 || config.MaxTotalBytes > 0 {
+		c.budget = newBudget(config.MaxItems, config.MaxTotalBytes)
 	}
+	if config.MaxItems > 0 {
+		c.maxCacheSize = math.MaxInt
+		c.policyCapacity = max(config.MaxItems/config.SegmentCount, 1)
+	}
+	if config.MaxTotalBytes > 0 {
+		c.maxBytes = 0
+	}
+
 	for i := range c.segments {
-		c.segments[i] = newSegment(c.maxCacheSize, c)
+		c.segments[i] = newSegment(c.maxCacheSize, c.maxBytes, c)
+	}
+
+	if config.CleanupInterval > 0 {
+		c.janitor = newJanitor(config.CleanupInterval, config.CleanupResolution)
+		go c.janitor.run(c.sweepExpired)
 	}
 
 	return c, nil
 }
 
-// set sets a key-value pair in the cache
-func (s *Segment) set(key string, value interface{}, ttl, defaultExpiration time.Duration) {
-	var expiration int64
+// writeOptions holds the settings of a single write besides the key and value.
+type writeOptions struct {
+	ttl       time.Duration  // Expiration as passed to Set
+	staleTTL  time.Duration  // Time the item is served as stale once ttl has passed
+	delta     time.Duration  // Time it took to compute the value
+	sliding   bool           // Whether hits extend the expiration by ttl again
+	lifetime  time.Duration  // Maximum lifetime of a sliding item, 0 if unlimited
+	condition writeCondition // Whether the key must be absent or present beforehand
+	version   uint64         // Version the key must have for ifVersion
+}
 
-	if ttl == 0 {
-		expiration = 0
-	} else if ttl == DefaultExpiration {
-		expiration = time.Now().Add(defaultExpiration).UnixNano()
-	} else if ttl > 0 {
-		expiration = time.Now().Add(ttl).UnixNano()
+// writeCondition restricts a write to keys that are absent or present.
+type writeCondition int
+
+const (
+	always    writeCondition = iota // Write whether or not the key is present
+	ifAbsent                        // Only write if the key is absent, or expired
+	ifPresent                       // Only write if the key is present and not expired
+	ifVersion                       // Only write if the key is present with the expected version
+)
+
+// set sets a key-value pair of the given weight in the cache.
+// A ttl of DefaultExpiration uses defaultExpiration, NoExpiration (or any other
+// negative ttl) never expires, and a positive ttl expires after that duration.
+// KeepTTL leaves the expiration of an existing item as it is. Otherwise, the
+// cache's Expiry, if any, may replace the ttl; see expireAfterWrite for how
+// the ttl turns into an expiration.
+// It returns the value the key had before, and whether it had one. An expired
+// item counts as absent, and is removed first. It returns ErrItemTooLarge if
+// the item alone outweighs the segment, and ErrKeyExists, ErrKeyNotFound or
+// ErrVersionMismatch if the write condition of options does not hold, leaving
+// the cache unchanged.
+func (s *Segment[K, V]) set(key K, value V, weight int64, options writeOptions, defaultExpiration time.Duration) (V, bool, error) {
+	if s.tooLarge(weight) {
+		var zero V
+		return zero, false, ErrItemTooLarge
 	}
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	return s.setLocked(key, value, weight, options, defaultExpiration)
+}
+
+// setLocked is set for callers holding the write lock.
+func (s *Segment[K, V]) setLocked(key K, value V, weight int64, options writeOptions, defaultExpiration time.Duration) (V, bool, error) {
+	var previous V
+	if s.tooLarge(weight) {
+		return previous, false, ErrItemTooLarge
+	}
+	s.markUsed()
+
+	now := time.Now().UnixNano()
+	ttl := options.ttl
+	if ttl == DefaultExpiration {
+		ttl = defaultExpiration
+	}
 
-	if itm, ok := s.items[key]; ok {
+	itm, ok := s.items[key]
+	if ok && itm.Expired() {
+		s.removeKey(key)
+		ok = false
+	}
+	if ok {
+		previous = itm.Value
+	}
+	switch {
+	case options.condition == ifAbsent && ok:
+		return previous, true, ErrKeyExists
+	case (options.condition == ifPresent || options.condition == ifVersion) && !ok:
+		return previous, false, ErrKeyNotFound
+	case options.condition == ifVersion && itm.version != options.version:
+		return previous, true, ErrVersionMismatch
+	}
+
+	keep := ok && ttl == KeepTTL
+	if ttl == KeepTTL && !ok {
+		ttl = NoExpiration
+	}
+	if expiry := s.cache.expiry; expiry != nil && !keep {
+		if ok {
+			ttl = expiry.ExpireAfterUpdate(key, value, ttl, itm.remaining(now))
+		} else {
+			ttl = expiry.ExpireAfterCreate(key, value, ttl)
+		}
+		if ttl == DefaultExpiration {
+			ttl = defaultExpiration
+		}
+	}
+
+	if ok {
 		// Update existing item
 		itm.Value = value
-		itm.Expiration = expiration
+		s.account(0, weight-itm.weight)
+		itm.weight = weight
+
+		s.policy.OnAccess(key) // An update counts as a use of the key
+	} else {
+		// Create a new item
+		itm = &Item[V]{
+			Value:     value,
+			heapIndex: -1,
+			weight:    weight,
+		}
 
-		s.queue.MoveToFront(itm.node) // Move to front as it's recently updated
+		s.policy.OnInsert(key)
 
-		return
+		s.items[key] = itm
+		s.account(1, weight)
+	}
+	itm.written = now
+	itm.delta = int64(options.delta)
+	itm.version = s.cache.versions.Add(1)
+	if !keep {
+		s.expireAfterWrite(key, itm, ttl, options, now)
 	}
 
-	// Create a new item
-	itm := &Item{
-		Value:      value,
-		Expiration: expiration,
+	// Ensure cache size and weight do not exceed max limits
+	for s.overCapacity() {
+		if !s.removeOldest() {
+			break
+		}
 	}
+	return previous, ok, nil
+}
 
-	itm.node = s.queue.PushFront(key) // Store key in LRU/FIFO list
+// expireAfterWrite sets the expiration of an item written at now with ttl,
+// which never expires unless it is positive. A positive ttl is shortened by
+// the cache's TTLJitter, and the item is then kept for another staleTTL, if
+// positive, during which it is served as stale. A sliding item expires ttl
+// after its last hit instead, but no later than its lifetime after the write.
+// The caller must hold the segment's write lock.
+func (s *Segment[K, V]) expireAfterWrite(key K, item *Item[V], ttl time.Duration, options writeOptions, now int64) {
+	var expiration int64
 
-	s.items[key] = itm
-	s.size++
+	item.stale, item.idle, item.deadline = 0, 0, 0
+	if ttl > 0 {
+		if options.sliding {
+			item.idle = int64(ttl)
+		}
+		expiration = now + int64(s.cache.jitter(ttl))
+		if options.staleTTL > 0 {
+			item.stale, expiration = expiration, expiration+int64(options.staleTTL)
+		}
+	}
+	if options.sliding && options.lifetime > 0 {
+		item.deadline = now + int64(options.lifetime)
+		if expiration == 0 || expiration > item.deadline {
+			expiration = item.deadline
+		}
+		item.stale = min(item.stale, expiration)
+	}
+
+	s.setExpiration(key, item, expiration)
+}
 
-	// Ensure cache size does not exceed max limit
-	for s.size > s.maxSize {
-		s.removeOldest()
+// account records a change in the number of items and the weight of the
+// segment, in the segment itself and in the shared budget, if any.
+func (s *Segment[K, V]) account(items int, weight int64) {
+	s.size += items
+	s.weight += weight
+	if s.cache.budget != nil {
+		s.cache.budget.add(items, weight)
+		if s.cache.budget.maxItems > 0 {
+			s.fitPolicy()
+		}
 	}
 }
 
-// get retrieves a value for a key from the cache. It also updates the LRU list
-func (s *Segment) get(key string) (interface{}, bool) {
-	if s.cache.evictionPolicy == "LRU" {
-		s.lock.Lock()
-		defer s.lock.Unlock()
+// fitPolicy resizes a ResizablePolicy as the segment borrows from the shared
+// budget or gives room back, doubling or halving its capacity so the cost of
+// resizing is amortized. The capacity stays between the segment's fair share
+// of MaxItems and MaxItems itself.
+func (s *Segment[K, V]) fitPolicy() {
+	policy, ok := s.policy.(ResizablePolicy)
+	if !ok {
+		return
+	}
+	capacity, limit := s.policyCapacity, int(s.cache.budget.maxItems)
+	for s.size > capacity && capacity < limit {
+		capacity = min(2*capacity, limit)
+	}
+	for s.size < capacity/4 && capacity/2 >= s.cache.policyCapacity {
+		capacity /= 2
+	}
+	if capacity != s.policyCapacity {
+		s.policyCapacity = capacity
+		policy.Resize(capacity)
+	}
+}
 
-		item, exists := s.items[key]
+// tooLarge reports whether an item of the given weight can never fit.
+func (s *Segment[K, V]) tooLarge(weight int64) bool {
+	if s.maxWeight > 0 && weight > s.maxWeight {
+		return true
+	}
+	return s.cache.budget != nil && s.cache.budget.tooLarge(weight)
+}
 
-		if !exists {
-			return nil, false
-		}
+// overCapacity reports whether the segment must evict items to respect its
+// own limits. The shared budget is enforced afterwards by Cache.reclaim, which
+// may take the room from other segments.
+func (s *Segment[K, V]) overCapacity() bool {
+	return s.size > s.maxSize || (s.maxWeight > 0 && s.weight > s.maxWeight)
+}
 
-		// If the item exists but is expired, remove it
-		if item.Expired() {
-			s.removeKey(key)
-			return nil, false
+// markUsed records that the segment was just read or written, so that
+// Cache.reclaim takes room from segments that went unused longer instead.
+func (s *Segment[K, V]) markUsed() {
+	if b := s.cache.budget; b != nil {
+		if now := b.clock.Load(); s.used.Load() != now {
+			s.used.Store(now)
 		}
-		// If the item exists and is not expired, move it to the front of LRU list
-		s.queue.MoveToFront(item.node)
+	}
+}
+
+// get retrieves a copy of the item for a key from the cache. It also records
+// the access with the eviction policy, and extends the expiration of a
+// sliding item.
+func (s *Segment[K, V]) get(key K) (Item[V], bool) {
+	s.markUsed()
+	if !s.concurrentAccess {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return s.getLocked(key)
+	}
 
-		return item.Value, true
-	} else if s.cache.evictionPolicy == "FIFO" {
-		s.lock.RLock()
-		item, exists := s.items[key]
+	s.lock.RLock()
+	item, exists := s.items[key]
+	if exists && !item.Expired() && item.idle == 0 && s.cache.expiry == nil {
+		s.policy.OnAccess(key)
+		copied := *item
 		s.lock.RUnlock()
+		return copied, true
+	}
+	s.lock.RUnlock()
 
-		if !exists {
-			return nil, false
-		}
+	if !exists {
+		return Item[V]{}, false
+	}
+
+	// The item expired, or its expiration may change on a hit, both of which
+	// need the write lock. It may have been replaced meanwhile.
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.getLocked(key)
+}
 
-		// If the item exists but is expired, remove it
-		if item.Expired() {
-			s.lock.Lock()
-			s.removeKey(key)
-			s.lock.Unlock()
-			return nil, false
+// getLocked is get for callers holding the write lock.
+func (s *Segment[K, V]) getLocked(key K) (Item[V], bool) {
+	item, exists := s.items[key]
+
+	if !exists {
+		return Item[V]{}, false
+	}
+
+	// If the item exists but is expired, remove it
+	if item.Expired() {
+		s.removeKey(key)
+		return Item[V]{}, false
+	}
+	// If the item exists and is not expired, let the policy know it was used
+	s.policy.OnAccess(key)
+
+	// A stale item is not extended, as it must be revalidated rather than kept alive by reads
+	if now := time.Now().UnixNano(); !item.staleAt(now) {
+		if item.idle > 0 {
+			s.extend(key, item, time.Duration(item.idle), now)
+		}
+		if expiry := s.cache.expiry; expiry != nil {
+			remaining := item.remaining(now)
+			if ttl := expiry.ExpireAfterRead(key, item.Value, remaining); ttl != remaining {
+				if ttl == DefaultExpiration {
+					ttl = s.cache.defaultExpiration
+				}
+				s.extend(key, item, ttl, now)
+			}
 		}
+	}
+	return *item, true
+}
 
-		return item.Value, true
+// extend makes an item expire ttl from now, or never unless ttl is positive,
+// keeping the length of its stale window but not going past its deadline.
+// The caller must hold the segment's write lock.
+func (s *Segment[K, V]) extend(key K, item *Item[V], ttl time.Duration, now int64) {
+	var expiration, stale int64
+	if ttl > 0 {
+		expiration = now + int64(ttl)
+		if item.stale != 0 {
+			stale, expiration = expiration, expiration+item.Expiration-item.stale
+		}
+	}
+	if item.deadline != 0 && (expiration == 0 || expiration > item.deadline) {
+		expiration = item.deadline
+		stale = min(stale, expiration)
 	}
 
-	return nil, false
+	item.stale = stale
+	s.setExpiration(key, item, expiration)
 }
 
 // removeKey removes a key from the cache
-func (s *Segment) removeKey(key string) {
+func (s *Segment[K, V]) removeKey(key K) {
 	if item, exists := s.items[key]; exists {
-		if s.cache.onEvicted != nil {
-			s.cache.onEvicted(key, item.Value)
+		if onEvicted := s.cache.onEvicted.Load(); onEvicted != nil {
+			(*onEvicted)(key, item.Value)
 		}
 
-		s.queue.Remove(item.node) // Remove item.node from LRU/FIFO
+		s.policy.OnRemove(key)
+		if item.heapIndex >= 0 {
+			s.expirations.remove(item.heapIndex)
+			s.updateNextExpiration()
+		}
 
-		delete(s.items, key) // Remove item from map
-		s.size--             // Update the segment size
+		delete(s.items, key)        // Remove item from map
+		s.account(-1, -item.weight) // Update the segment size and weight
 	}
 }
 
 // Delete removes a key from the cache
-func (s *Segment) delete(key string) {
+func (s *Segment[K, V]) delete(key K) {
 	s.lock.Lock()
 	s.removeKey(key)
 	s.lock.Unlock()
 }
 
-// removeOldest removes the least recently used item from the cache
-func (s *Segment) removeOldest() {
-	if oldest := s.queue.Back(); oldest != nil {
-		s.removeKey(oldest.Value.(string))
+// evict removes the item chosen by the eviction policy if the segment holds
+// more than keep items, and reports whether it did.
+func (s *Segment[K, V]) evict(keep int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.size > keep && s.removeOldest()
+}
+
+// removeOldest removes the item chosen by the eviction policy from the cache.
+// It reports whether an item was removed, which is not the case if the policy
+// chose a key the segment does not hold; callers then stop evicting rather
+// than asking the policy again forever.
+func (s *Segment[K, V]) removeOldest() bool {
+	key, ok := s.policy.Victim()
+	if !ok {
+		return false
+	}
+	if _, exists := s.items[key]; !exists {
+		return false
 	}
+	s.removeKey(key)
+	return true
 }
 
 // getWithExpiration returns an item and its expiration time from the cache.
 // It returns the item or nil, the expiration time if one is set (if the item
 // never expires a zero value for time.Time is returned), and a bool indicating
 // whether the key was found.
-func (s *Segment) getWithExpiration(key string) (interface{}, time.Time, bool) {
+func (s *Segment[K, V]) getWithExpiration(key K) (V, time.Time, bool) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
 	item, exists := s.items[key]
 	if !exists || item.Expired() {
-		return nil, time.Time{}, false
+		var zero V
+		return zero, time.Time{}, false
 	}
 	expiration := time.Time{}
 	if item.Expiration > 0 {
@@ -269,17 +682,17 @@ func (s *Segment) getWithExpiration(key string) (interface{}, time.Time, bool) {
 	return item.Value, expiration, true
 }
 
-func (s *Segment) itemCount() int {
+func (s *Segment[K, V]) itemCount() int {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	return len(s.items)
 }
 
-func (s *Segment) getItems() map[string]interface{} {
+func (s *Segment[K, V]) getItems() map[K]V {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	result := make(map[string]interface{})
+	result := make(map[K]V)
 	for key, item := range s.items {
 		if !item.Expired() {
 			result[key] = item.Value
@@ -289,136 +702,208 @@ func (s *Segment) getItems() map[string]interface{} {
 }
 
 // increment an item of type int, int8, int16, int32, int64, uintptr, uint,
-// uint8, uint32, or uint64, float32 or float64 by n. Returns an error if the
-// item's value is not an integer, if it was not found, or if it is not
-// possible to increment it by n. To retrieve the incremented value, use one
-// of the specialized methods, e.g. IncrementInt64.
-func (s *Segment) increment(k string, n int64) error {
-	s.lock.Lock()         // 使用正确的锁名称
-	defer s.lock.Unlock() // 使用 defer 确保锁一定会被释放
+// uint8, uint32, or uint64, float32 or float64 by n, handling overflow
+// according to mode. Returns an error if the item's value is not a number,
+// if it was not found, or if it is not possible to increment it by n. To
+// retrieve the incremented value, use one of the specialized methods, e.g.
+// IncrementInt64.
+func (s *Segment[K, V]) increment(k K, n int64, mode OverflowMode) error {
+	mag, neg := magnitude(n)
+	return s.computeNumber(k, func(value V) (any, error) {
+		return addNumber(k, any(value), mag, neg, "increment", mode)
+	})
+}
 
-	v, found := s.items[k]
-	if !found || v.Expired() {
-		return fmt.Errorf("item %s not found or expired", k)
-	}
+// decrement an item of type int, int8, int16, int32, int64, uintptr, uint,
+// uint8, uint32, or uint64, float32 or float64 by n, handling overflow
+// according to mode. Returns an error if the item's value is not a number,
+// if it was not found, or if it is not possible to decrement it by n. To
+// retrieve the decremented value, use one of the specialized methods, e.g.
+// DecrementInt64.
+func (s *Segment[K, V]) decrement(k K, n int64, mode OverflowMode) error {
+	mag, neg := magnitude(n)
+	return s.computeNumber(k, func(value V) (any, error) {
+		return addNumber(k, any(value), mag, !neg, "decrement", mode)
+	})
+}
 
-	switch val := v.Value.(type) {
+// addNumber adds the delta given by its magnitude mag and its sign neg to
+// value, handling overflow according to mode. The switch works on the dynamic
+// type of the value, so the result is always assignable back to V. op names
+// the operation in errors.
+func addNumber[K comparable](k K, value any, mag uint64, neg bool, op string, mode OverflowMode) (any, error) {
+	switch val := value.(type) {
 	case int:
-		v.Value = val + int(n)
+		return addInteger(k, val, math.MinInt, math.MaxInt, mag, neg, op, mode)
 	case int8:
-		v.Value = val + int8(n)
+		return addInteger(k, val, math.MinInt8, math.MaxInt8, mag, neg, op, mode)
 	case int16:
-		v.Value = val + int16(n)
+		return addInteger(k, val, math.MinInt16, math.MaxInt16, mag, neg, op, mode)
 	case int32:
-		v.Value = val + int32(n)
+		return addInteger(k, val, math.MinInt32, math.MaxInt32, mag, neg, op, mode)
 	case int64:
-		v.Value = val + n
+		return addInteger(k, val, math.MinInt64, math.MaxInt64, mag, neg, op, mode)
 	case uint:
-		v.Value = val + uint(n)
+		return addInteger(k, val, 0, math.MaxUint, mag, neg, op, mode)
 	case uintptr:
-		v.Value = val + uintptr(n)
+		return addInteger(k, val, 0, ^uintptr(0), mag, neg, op, mode)
 	case uint8:
-		v.Value = val + uint8(n)
+		return addInteger(k, val, 0, math.MaxUint8, mag, neg, op, mode)
 	case uint16:
-		v.Value = val + uint16(n)
+		return addInteger(k, val, 0, math.MaxUint16, mag, neg, op, mode)
 	case uint32:
-		v.Value = val + uint32(n)
+		return addInteger(k, val, 0, math.MaxUint32, mag, neg, op, mode)
 	case uint64:
-		v.Value = val + uint64(n)
+		return addInteger(k, val, 0, math.MaxUint64, mag, neg, op, mode)
 	case float32:
-		v.Value = val + float32(n)
+		return addFloat(k, val, signed(mag, neg), math.MaxFloat32, op, mode)
 	case float64:
-		v.Value = val + float64(n)
-	default:
-		return fmt.Errorf("the value for %s is not a number or not suitable for increment", k)
+		return addFloat(k, val, signed(mag, neg), math.MaxFloat64, op, mode)
+	}
+	return nil, fmt.Errorf("the value for %v is not a number or not suitable for %s", k, op)
+}
+
+// computeNumber replaces the numeric value of k by the result of f in place,
+// see updateValue. It returns an error if k was not found or expired, or the
+// error of f, leaving the value unchanged.
+func (s *Segment[K, V]) computeNumber(k K, f func(value V) (any, error)) error {
+	s.lock.Lock()
+	_, found, err := s.computeNumberLocked(k, f)
+	s.lock.Unlock()
+
+	if !found {
+		return fmt.Errorf("item %v not found or expired", k)
+	}
+	if err == nil {
+		s.cache.reclaim(s) // The new value may weigh more
+	}
+	return err
+}
+
+// computeNumberLocked is computeNumber for callers holding the write lock.
+// It returns the new value, and whether k was found.
+func (s *Segment[K, V]) computeNumberLocked(k K, f func(value V) (any, error)) (V, bool, error) {
+	var zero V
+	item, found := s.items[k]
+	if found && item.Expired() {
+		s.removeKey(k)
+		found = false
 	}
+	if !found {
+		return zero, false, nil
+	}
+
+	result, err := f(item.Value)
+	if err != nil {
+		return zero, true, err
+	}
+	value := result.(V)
+	return value, true, s.updateValue(k, item, value)
+}
 
-	s.items[k] = v
+// updateValue replaces the value of an item in place, as counters do. Unlike
+// setLocked, it keeps everything else about the item: its expiration, its
+// write and compute times, which RefreshAfter and XFetchBeta rely on, and its
+// position in the eviction order. Only its weight and version change along.
+// The caller must hold the write lock.
+func (s *Segment[K, V]) updateValue(key K, item *Item[V], value V) error {
+	weight := s.cache.weigh(key, value)
+	if s.tooLarge(weight) {
+		return ErrItemTooLarge
+	}
+	s.markUsed()
+
+	item.Value = value
+	s.account(0, weight-item.weight)
+	item.weight = weight
+	item.version = s.cache.versions.Add(1)
+
+	for s.overCapacity() {
+		if !s.removeOldest() {
+			break
+		}
+	}
 	return nil
 }
 
-// decrement an item of type int, int8, int16, int32, int64, uintptr, uint,
-// uint8, uint32, or uint64, float32 or float64 by n. Returns an error if the
-// item's value is not an integer, if it was not found, or if it is not
-// possible to decrement it by n. To retrieve the decremented value, use one
-// of the specialized methods, e.g. DecrementInt64.
-func (s *Segment) decrement(k string, n int64) error {
+// setExpiration sets the expiration of an item and keeps the expiration heap in sync.
+// The caller must hold the segment's write lock.
+func (s *Segment[K, V]) setExpiration(key K, item *Item[V], expiration int64) {
+	item.Expiration = expiration
+
+	switch {
+	case expiration > 0 && item.heapIndex < 0:
+		s.expirations.push(key, item)
+	case expiration > 0:
+		s.expirations.fix(item.heapIndex)
+	case item.heapIndex >= 0:
+		s.expirations.remove(item.heapIndex)
+	}
+	s.updateNextExpiration()
+
+	if expiration > 0 && s.cache.janitor != nil {
+		s.cache.janitor.schedule(expiration)
+	}
+}
+
+// deleteExpired removes at most limit items that expired before now, earliest
+// first, using the expiration heap. It returns the number of items removed and
+// the earliest expiration still pending in the segment, or 0 if there is none.
+func (s *Segment[K, V]) deleteExpired(now int64, limit int) (int, int64) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	v, found := s.items[k]
-	if !found || v.Expired() {
-		return fmt.Errorf("item %s not found or expired", k)
-	}
-	switch val := v.Value.(type) {
-	case int:
-		v.Value = val - int(n)
-	case int8:
-		v.Value = val - int8(n)
-	case int16:
-		v.Value = val - int16(n)
-	case int32:
-		v.Value = val - int32(n)
-	case int64:
-		v.Value = val - n
-	case uint:
-		if uint(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
-		}
-		v.Value = val - uint(n)
-	case uintptr:
-		if uintptr(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
+	removed := 0
+	for {
+		entry, ok := s.expirations.peek()
+		if !ok {
+			return removed, 0
 		}
-		v.Value = val - uintptr(n)
-	case uint8:
-		if uint8(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
+		if removed >= limit || entry.item.Expiration >= now {
+			return removed, entry.item.Expiration
 		}
-		v.Value = val - uint8(n)
-	case uint16:
-		if uint16(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
-		}
-		v.Value = val - uint16(n)
-	case uint32:
-		if uint32(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
-		}
-		v.Value = val - uint32(n)
-	case uint64:
-		if uint64(n) > val {
-			return fmt.Errorf("decrement would result in negative value for key %s", k)
-		}
-		v.Value = val - uint64(n)
-	case float32:
-		v.Value = val - float32(n)
-	case float64:
-		v.Value = val - float64(n)
-	default:
-		return fmt.Errorf("the value for %s is not a number or not suitable for decrement", k)
+		s.removeKey(entry.key)
+		removed++
 	}
-	s.items[k] = v
-	return nil
 }
 
 // clear removes all items from the segment.
-func (s *Segment) clear() {
+func (s *Segment[K, V]) clear() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.items = make(map[string]*Item)
-	s.queue.Init()
-	s.size = 0
+	s.items = make(map[K]*Item[V])
+	s.resetPolicy()
+	s.expirations = nil
+	s.updateNextExpiration()
+	s.account(-s.size, -s.weight)
+}
+
+// updateNextExpiration publishes the earliest expiration of the segment after
+// the expiration heap changed. The caller must hold the segment's write lock.
+func (s *Segment[K, V]) updateNextExpiration() {
+	var next int64
+	if entry, ok := s.expirations.peek(); ok {
+		next = entry.item.Expiration
+	}
+	if s.nextExpiration.Load() != next {
+		s.nextExpiration.Store(next)
+	}
 }
 
 // getSegment computes the segment for a given key.
 // It uses bit manipulation (bitwise AND operation) instead of modulo operation for efficiency.
 // Bitwise operations are generally faster than arithmetic operations like modulo,
 // especially when dealing with large amounts of data.
-func (c *Cache) getSegment(key string) *Segment {
-	hasher := c.hashFunc()
-	_, err := hasher.Write([]byte(key))
+func (c *Cache[K, V]) getSegment(key K) *Segment[K, V] {
+	return c.segmentFor(c.hashFunc(), key)
+}
+
+// segmentFor computes the segment for a given key with hasher, which it
+// resets first, so batch operations can hash all their keys with one hasher.
+func (c *Cache[K, V]) segmentFor(hasher hash.Hash32, key K) *Segment[K, V] {
+	hasher.Reset()
+	err := writeKey(hasher, key)
 	if err != nil {
 		log.Printf("Error hashing key: %v", err)
 		return nil
@@ -430,22 +915,279 @@ func (c *Cache) getSegment(key string) *Segment {
 	return c.segments[hasher.Sum32()&(uint32(c.segmentCount)-1)]
 }
 
-// Set sets a key-value pair in the cache (public interface)
-func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
+// writeKey writes the byte representation of key to the hasher.
+// Strings and numbers are written directly; other comparable types are
+// walked with reflection by writeValue, which is slower. Keys equal under ==
+// always write the same bytes. As with Go maps, a key containing NaN never
+// equals anything, so it can be set but never found.
+func writeKey[K comparable](hasher hash.Hash, key K) error {
+	var buf [8]byte
+	var err error
+
+	switch k := any(key).(type) {
+	case string:
+		_, err = hasher.Write([]byte(k))
+		return err
+	case int:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case int8:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case int16:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case int32:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case uint:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case uint8:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case uint16:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case uint32:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case uint64:
+		binary.LittleEndian.PutUint64(buf[:], k)
+	case uintptr:
+		binary.LittleEndian.PutUint64(buf[:], uint64(k))
+	case float32:
+		if k == 0 {
+			k = 0 // -0 and +0 are equal keys and must land in the same segment
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(math.Float32bits(k)))
+	case float64:
+		if k == 0 {
+			k = 0 // -0 and +0 are equal keys and must land in the same segment
+		}
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(k))
+	default:
+		return writeValue(hasher, reflect.ValueOf(key))
+	}
+
+	_, err = hasher.Write(buf[:])
+	return err
+}
+
+// writeValue writes the byte representation of v to the hasher, walking
+// structs, arrays and interfaces down to their basic values, so that equal
+// composite keys hash alike: -0 and +0 write the same bytes wherever they
+// are nested, and blank struct fields, which == ignores, are skipped.
+// Pointers and channels are written as their address.
+func writeValue(hasher hash.Hash, v reflect.Value) error {
+	var buf [8]byte
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil // A nil interface
+	case reflect.String:
+		_, err := io.WriteString(hasher, v.String())
+		return err
+	case reflect.Bool:
+		if v.Bool() {
+			buf[0] = 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		binary.LittleEndian.PutUint64(buf[:], floatBits(real(v.Complex())))
+		if _, err := hasher.Write(buf[:]); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf[:], floatBits(imag(v.Complex())))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Pointer()))
+	case reflect.Interface:
+		return writeValue(hasher, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := writeValue(hasher, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name == "_" {
+				continue
+			}
+			if err := writeValue(hasher, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot hash a key of kind %s", v.Kind())
+	}
+
+	_, err := hasher.Write(buf[:])
+	return err
+}
+
+// floatBits returns the bits of f, with -0 turned into +0 as they are equal.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		f = 0
+	}
+	return math.Float64bits(f)
+}
+
+// Set sets a key-value pair in the cache (public interface).
+// It returns ErrItemTooLarge, leaving the cache unchanged, if the item alone
+// weighs more than a segment may hold.
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) error {
 	segment := c.getSegment(key)
-	if segment != nil {
-		segment.set(key, value, ttl, c.defaultExpiration)
+	if segment == nil {
+		return nil
+	}
+	_, _, err := c.set(segment, key, value, c.writeOptions(ttl))
+	return err
+}
+
+// SetWithStale sets a key-value pair in the cache that is served as stale for
+// another staleTTL once ttl has passed, instead of the cache's StaleTTL.
+// Get keeps returning a stale value, and starts revalidating it if a Loader
+// is configured; GetWithStale reports whether the value is stale.
+func (c *Cache[K, V]) SetWithStale(key K, value V, ttl, staleTTL time.Duration) error {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return nil
+	}
+	options := c.writeOptions(ttl)
+	options.staleTTL = staleTTL
+	_, _, err := c.set(segment, key, value, options)
+	return err
+}
+
+// SetWithDelta sets a key-value pair in the cache and records delta, the time
+// it took to compute value. With XFetchBeta configured, the longer a value
+// takes to compute, the earlier before its expiration Get may report a miss.
+func (c *Cache[K, V]) SetWithDelta(key K, value V, ttl, delta time.Duration) error {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return nil
+	}
+	options := c.writeOptions(ttl)
+	options.delta = delta
+	_, _, err := c.set(segment, key, value, options)
+	return err
+}
+
+// SetSliding sets a key-value pair in the cache that expires ttl after it was
+// last read by Get, rather than after this write, whether or not the cache has
+// SlidingExpiration configured. However often it is read, it expires no later
+// than maxLifetime after this write, unless maxLifetime is 0.
+func (c *Cache[K, V]) SetSliding(key K, value V, ttl, maxLifetime time.Duration) error {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return nil
+	}
+	options := c.writeOptions(ttl)
+	options.sliding, options.lifetime = true, maxLifetime
+	_, _, err := c.set(segment, key, value, options)
+	return err
+}
+
+// writeOptions returns the options of a write with the given ttl and the
+// cache's defaults for everything else.
+func (c *Cache[K, V]) writeOptions(ttl time.Duration) writeOptions {
+	return writeOptions{
+		ttl:      ttl,
+		staleTTL: c.staleTTL,
+		sliding:  c.sliding,
+		lifetime: c.maxLifetime,
 	}
 }
 
-// Get retrieves a value for a key from the cache (public interface)
-func (c *Cache) Get(key string) (interface{}, bool) {
+// set stores a key-value pair in segment and makes room for it in the shared
+// budget, if any. It returns the value the key had before and whether it had
+// one, as Segment.set does.
+func (c *Cache[K, V]) set(segment *Segment[K, V], key K, value V, options writeOptions) (V, bool, error) {
+	previous, found, err := segment.set(key, value, c.weigh(key, value), options, c.defaultExpiration)
+	if err != nil {
+		return previous, found, err
+	}
+	c.reclaim(segment)
+	return previous, found, nil
+}
+
+// Get retrieves a value for a key from the cache (public interface).
+// If a Loader is configured, a missing key is loaded and stored first, and an
+// item that is stale, older than RefreshAfter or expiring early (see
+// XFetchBeta) is returned while it is reloaded in the background. Get reports
+// a miss if the Loader fails; use GetOrLoad to see the error. Without a
+// Loader, an item expiring early is reported as a miss.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	value, _, found := c.GetWithStale(key)
+	return value, found
+}
+
+// GetWithStale is like Get, but also reports whether the value is stale, i.e.
+// past its ttl and served for at most StaleTTL longer while it is revalidated.
+func (c *Cache[K, V]) GetWithStale(key K) (V, bool, bool) {
 	segment := c.getSegment(key)
-	return segment.get(key)
+	if value, stale, found := c.lookup(segment, key, nil); found || c.loader == nil {
+		return value, stale, found
+	}
+
+	value, err := c.GetOrLoad(context.Background(), key, c.loaderFor(key))
+	return value, false, err == nil
+}
+
+// lookup retrieves a value for a key from segment and reports whether it is
+// stale. A value that is stale, older than refreshAfter or expiring early is
+// reloaded in the background with loader, or with the configured Loader if
+// loader is nil. If there is no loader, a value expiring early is a miss.
+func (c *Cache[K, V]) lookup(segment *Segment[K, V], key K, loader func(ctx context.Context) (V, time.Duration, error)) (V, bool, bool) {
+	item, found := segment.get(key)
+	if !found {
+		return item.Value, false, false
+	}
+	return c.revalidate(segment, key, &item, loader)
+}
+
+// revalidate is lookup for an item already retrieved from segment.
+func (c *Cache[K, V]) revalidate(segment *Segment[K, V], key K, item *Item[V], loader func(ctx context.Context) (V, time.Duration, error)) (V, bool, bool) {
+	now := time.Now().UnixNano()
+	stale := item.staleAt(now)
+	early := !stale && c.expiresEarly(item, now)
+	if stale || early || (c.refreshAfter > 0 && now-item.written > int64(c.refreshAfter)) {
+		if loader == nil && c.loader != nil {
+			loader = c.loaderFor(key)
+		}
+		if loader != nil {
+			c.refresh(segment, key, loader)
+		} else if early {
+			var zero V
+			return zero, false, false
+		}
+	}
+	return item.Value, stale, true
+}
+
+// expiresEarly implements XFetch, probabilistic early expiration: an item is
+// treated as expired before its time with a probability that grows as its
+// expiration approaches, and the faster the longer the value took to compute.
+// Among many readers, one is then likely to recompute the value before it
+// actually expires, instead of all of them at the same moment.
+func (c *Cache[K, V]) expiresEarly(item *Item[V], now int64) bool {
+	if c.xfetchBeta <= 0 || item.delta <= 0 || item.Expiration == 0 {
+		return false
+	}
+	expiration := item.Expiration
+	if item.stale != 0 {
+		expiration = item.stale // Recompute before the value goes stale
+	}
+	// -log(u) for u in (0, 1] is exponentially distributed with mean 1
+	gap := float64(item.delta) * c.xfetchBeta * -math.Log(1-c.random())
+	return float64(now)+gap >= float64(expiration)
 }
 
 // Delete removes a key from the cache (public interface)
-func (c *Cache) Delete(key string) {
+func (c *Cache[K, V]) Delete(key K) {
 	segment := c.getSegment(key)
 	if segment != nil {
 		segment.delete(key)
@@ -453,16 +1195,17 @@ func (c *Cache) Delete(key string) {
 }
 
 // GetWithExpiration returns an item and its expiration time from the cache.
-func (c *Cache) GetWithExpiration(key string) (interface{}, time.Time, bool) {
+func (c *Cache[K, V]) GetWithExpiration(key K) (V, time.Time, bool) {
 	segment := c.getSegment(key)
 	if segment == nil {
-		return nil, time.Time{}, false
+		var zero V
+		return zero, time.Time{}, false
 	}
 	return segment.getWithExpiration(key)
 }
 
 // ItemCount returns the number of items in the cache.
-func (c *Cache) ItemCount() int {
+func (c *Cache[K, V]) ItemCount() int {
 	count := 0
 	for _, segment := range c.segments {
 		count += segment.itemCount()
@@ -471,8 +1214,8 @@ func (c *Cache) ItemCount() int {
 }
 
 // Items copies all unexpired items in the cache into a new map and returns it.
-func (c *Cache) Items() map[string]interface{} {
-	items := make(map[string]interface{})
+func (c *Cache[K, V]) Items() map[K]V {
+	items := make(map[K]V)
 	for _, segment := range c.segments {
 		segmentItems := segment.getItems()
 		for k, v := range segmentItems {
@@ -484,7 +1227,7 @@ func (c *Cache) Items() map[string]interface{} {
 
 // Item retrieves an item from the cache, along with its existence.
 // It returns a pointer to the Item and a boolean indicating whether the item was found.
-func (c *Cache) Item(key string) (*Item, bool) {
+func (c *Cache[K, V]) Item(key K) (*Item[V], bool) {
 	segment := c.getSegment(key)
 	segment.lock.RLock()
 	defer segment.lock.RUnlock()
@@ -494,25 +1237,25 @@ func (c *Cache) Item(key string) (*Item, bool) {
 }
 
 // Increment increases the value of an item by n.
-func (c *Cache) Increment(k string, n int64) error {
+func (c *Cache[K, V]) Increment(k K, n int64) error {
 	segment := c.getSegment(k)
 	if segment == nil {
 		return errors.New("key not found")
 	}
-	return segment.increment(k, n)
+	return segment.increment(k, n, c.overflow)
 }
 
 // Decrement decreases the value of an item by n.
-func (c *Cache) Decrement(k string, n int64) error {
+func (c *Cache[K, V]) Decrement(k K, n int64) error {
 	segment := c.getSegment(k)
 	if segment == nil {
 		return errors.New("key not found")
 	}
-	return segment.decrement(k, n)
+	return segment.decrement(k, n, c.overflow)
 }
 
 // Flush clears all cached items from the cache.
-func (c *Cache) Flush() {
+func (c *Cache[K, V]) Flush() {
 	for _, segment := range c.segments {
 		segment.clear()
 	}
@@ -520,9 +1263,63 @@ func (c *Cache) Flush() {
 
 // OnEvicted sets an (optional) function that is called with the key and value
 // when an item is evicted from the cache. (Including when it is deleted manually,
-// but not when it is overwritten.) Set to nil to disable.
-func (c *Cache) OnEvicted(f func(string, interface{})) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.onEvicted = f
+// but not when it is overwritten, unless it had expired.) Set to nil to disable.
+// It is stored atomically, as the janitor and background loads evict items
+// from their own goroutines.
+func (c *Cache[K, V]) OnEvicted(f func(K, V)) {
+	if f == nil {
+		c.onEvicted.Store(nil)
+		return
+	}
+	c.onEvicted.Store(&f)
+}
+
+// DeleteExpired removes all expired items from the cache.
+// OnEvicted is called for every item removed.
+func (c *Cache[K, V]) DeleteExpired() {
+	c.sweepExpired(nil)
+}
+
+// sweepExpired removes expired items one segment at a time, in batches of
+// sweepBatchSize, so only a single segment is locked at any moment and never
+// for long. Segments with nothing due are skipped without locking them, so
+// the cost is proportional to the number of expired items rather than the
+// size of the cache. It returns early once stop is closed.
+// The earliest expiration still pending in the cache is returned, or 0 if no
+// item is due to expire.
+func (c *Cache[K, V]) sweepExpired(stop <-chan struct{}) int64 {
+	var next int64
+	for _, segment := range c.segments {
+		if due := segment.nextExpiration.Load(); due == 0 || due >= time.Now().UnixNano() {
+			if due > 0 && (next == 0 || due < next) {
+				next = due
+			}
+			continue
+		}
+		for {
+			select {
+			case <-stop:
+				return 0
+			default:
+			}
+
+			removed, pending := segment.deleteExpired(time.Now().UnixNano(), sweepBatchSize)
+			if removed < sweepBatchSize {
+				if pending > 0 && (next == 0 || pending < next) {
+					next = pending
+				}
+				break
+			}
+		}
+	}
+	return next
+}
+
+// Close stops the janitor, if one was configured. The cache remains usable
+// afterwards, but expired items are only removed lazily again.
+// It is safe to call Close more than once.
+func (c *Cache[K, V]) Close() {
+	if c.janitor != nil {
+		c.janitor.close()
+	}
 }
@@ -0,0 +1,149 @@
+package swiftcache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrOverflow is returned by increments and decrements whose result does not
+// fit the type of the value, when overflow is handled with OverflowError, and
+// by unsigned values going below zero with OverflowDefault.
+var ErrOverflow = errors.New("numeric overflow")
+
+// OverflowMode tells increments and decrements what to do when the result
+// does not fit the type of the value.
+type OverflowMode int
+
+const (
+	// OverflowDefault wraps around, except that an unsigned value going
+	// below zero fails with ErrOverflow, as Decrement always did.
+	OverflowDefault  OverflowMode = iota
+	OverflowWrap                  // Wrap around, e.g. int8(127)+1 is -128; floats become ±Inf
+	OverflowSaturate              // Stop at the minimum or maximum value of the type
+	OverflowError                 // Fail with ErrOverflow and leave the value unchanged
+)
+
+// integer is the set of integer types counters support.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IncrementWithOverflow is like Increment, but handles overflow according to
+// mode instead of the cache's Overflow setting.
+func (c *Cache[K, V]) IncrementWithOverflow(k K, n int64, mode OverflowMode) error {
+	segment := c.getSegment(k)
+	if segment == nil {
+		return errors.New("key not found")
+	}
+	return segment.increment(k, n, mode)
+}
+
+// DecrementWithOverflow is like Decrement, but handles overflow according to
+// mode instead of the cache's Overflow setting.
+func (c *Cache[K, V]) DecrementWithOverflow(k K, n int64, mode OverflowMode) error {
+	segment := c.getSegment(k)
+	if segment == nil {
+		return errors.New("key not found")
+	}
+	return segment.decrement(k, n, mode)
+}
+
+// IncrementFloat increases the value of an item of type float32 or float64 by
+// n, keeping its expiration. Unlike Increment, the delta is not truncated to
+// an integer. It returns an error if the item was not found or expired, or
+// its value is not a float32 or float64.
+func (c *Cache[K, V]) IncrementFloat(k K, n float64) error {
+	return c.addFloatValue(k, n, "increment")
+}
+
+// DecrementFloat decreases the value of an item of type float32 or float64 by
+// n, keeping its expiration. It returns an error if the item was not found or
+// expired, or its value is not a float32 or float64.
+func (c *Cache[K, V]) DecrementFloat(k K, n float64) error {
+	return c.addFloatValue(k, -n, "decrement")
+}
+
+// addFloatValue adds d to the floating point value of k.
+func (c *Cache[K, V]) addFloatValue(k K, d float64, op string) error {
+	segment := c.getSegment(k)
+	if segment == nil {
+		return errors.New("key not found")
+	}
+	return segment.computeNumber(k, func(value V) (any, error) {
+		switch val := any(value).(type) {
+		case float32:
+			return addFloat(k, val, d, math.MaxFloat32, op, c.overflow)
+		case float64:
+			return addFloat(k, val, d, math.MaxFloat64, op, c.overflow)
+		}
+		return nil, fmt.Errorf("the value for %v is not a float32 or float64", k)
+	})
+}
+
+// addInteger adds the delta given by its magnitude mag and its sign neg to v,
+// an integer ranging from lo to hi, handling overflow according to mode. The
+// bounds are compared in uint64 arithmetic, which is exact for differences
+// within a type even when v or lo are negative.
+func addInteger[K comparable, T integer](k K, v, lo, hi T, mag uint64, neg bool, op string, mode OverflowMode) (T, error) {
+	var overflow bool
+	var wrapped, bound T
+	if neg {
+		overflow = mag > uint64(v)-uint64(lo)
+		wrapped, bound = v-T(mag), lo
+	} else {
+		overflow = mag > uint64(hi)-uint64(v)
+		wrapped, bound = v+T(mag), hi
+	}
+	if !overflow {
+		return wrapped, nil
+	}
+
+	switch mode {
+	case OverflowSaturate:
+		return bound, nil
+	case OverflowError:
+		return v, fmt.Errorf("%w: %s would exceed the range of %T for key %v", ErrOverflow, op, v, k)
+	case OverflowDefault:
+		if neg && lo == 0 {
+			return v, fmt.Errorf("%w: %s would result in negative value for key %v", ErrOverflow, op, k)
+		}
+	}
+	return wrapped, nil
+}
+
+// addFloat adds d to v, a floating point number whose largest finite value is
+// max, handling overflow according to mode. Only a finite value turning
+// infinite counts as overflow; infinite inputs and NaN behave as usual.
+func addFloat[K comparable, T float32 | float64](k K, v T, d, max float64, op string, mode OverflowMode) (T, error) {
+	result := T(float64(v) + d)
+	if !math.IsInf(float64(result), 0) || math.IsInf(float64(v), 0) || math.IsInf(d, 0) {
+		return result, nil
+	}
+
+	switch mode {
+	case OverflowSaturate:
+		return T(math.Copysign(max, float64(result))), nil
+	case OverflowError:
+		return v, fmt.Errorf("%w: %s would exceed the range of %T for key %v", ErrOverflow, op, v, k)
+	}
+	return result, nil
+}
+
+// magnitude splits n into its absolute value and its sign. The absolute value
+// of math.MinInt64 fits a uint64.
+func magnitude(n int64) (uint64, bool) {
+	if n < 0 {
+		return -uint64(n), true
+	}
+	return uint64(n), false
+}
+
+// signed turns a magnitude and a sign back into a float64.
+func signed(mag uint64, neg bool) float64 {
+	if neg {
+		return -float64(mag)
+	}
+	return float64(mag)
+}
@@ -0,0 +1,169 @@
+package swiftcache
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestOverflowModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		n     int64
+		mode  OverflowMode
+		want  any
+		err   bool
+	}{
+		{"int8 wrap", int8(127), 1, OverflowWrap, int8(-128), false},
+		{"int8 saturate", int8(127), 1, OverflowSaturate, int8(127), false},
+		{"int8 error", int8(127), 1, OverflowError, int8(127), true},
+		{"int8 default", int8(127), 1, OverflowDefault, int8(-128), false},
+		{"int8 below min", int8(-128), -1, OverflowSaturate, int8(-128), false},
+		{"int8 large delta", int8(0), 1000, OverflowSaturate, int8(127), false},
+		{"int8 large delta wrap", int8(0), 1000, OverflowWrap, int8(-24), false},
+		{"int16 saturate", int16(math.MaxInt16), 1, OverflowSaturate, int16(math.MaxInt16), false},
+		{"int32 error", int32(math.MinInt32), -1, OverflowError, int32(math.MinInt32), true},
+		{"int64 saturate", int64(math.MaxInt64), math.MaxInt64, OverflowSaturate, int64(math.MaxInt64), false},
+		{"int64 min delta", int64(0), math.MinInt64, OverflowError, int64(math.MinInt64), false},
+		{"int saturate", int(math.MinInt), -1, OverflowSaturate, int(math.MinInt), false},
+		{"uint8 wrap", uint8(255), 1, OverflowWrap, uint8(0), false},
+		{"uint8 saturate", uint8(255), 1, OverflowSaturate, uint8(255), false},
+		{"uint8 below zero wrap", uint8(0), -1, OverflowWrap, uint8(255), false},
+		{"uint8 below zero saturate", uint8(3), -5, OverflowSaturate, uint8(0), false},
+		{"uint8 below zero default", uint8(0), -1, OverflowDefault, uint8(0), true},
+		{"uint16 error", uint16(math.MaxUint16), 1, OverflowError, uint16(math.MaxUint16), true},
+		{"uint32 saturate", uint32(math.MaxUint32 - 1), 5, OverflowSaturate, uint32(math.MaxUint32), false},
+		{"uint64 within range", uint64(math.MaxUint64 - 1), 1, OverflowError, uint64(math.MaxUint64), false},
+		{"uint saturate", uint(1), -2, OverflowSaturate, uint(0), false},
+		{"uintptr saturate", ^uintptr(0), 1, OverflowSaturate, ^uintptr(0), false},
+		{"float32 saturate", float32(math.MaxFloat32), math.MaxInt64, OverflowSaturate, float32(math.MaxFloat32), false},
+		{"float64 in range", float64(1.5), 2, OverflowError, float64(3.5), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, _ := NewCache()
+			tc.Set("k", tt.value, NoExpiration)
+			err := tc.IncrementWithOverflow("k", tt.n, tt.mode)
+			if tt.err != (err != nil) {
+				t.Fatalf("got error %v, expected error: %t", err, tt.err)
+			}
+			if err != nil && !errors.Is(err, ErrOverflow) {
+				t.Errorf("got %v, expected ErrOverflow", err)
+			}
+			if x, _ := tc.Get("k"); x != tt.want {
+				t.Errorf("got %v (%T), expected %v (%T)", x, x, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecrementWithOverflow(t *testing.T) {
+	tc, _ := NewCache()
+
+	tc.Set("a", int8(-127), NoExpiration)
+	if err := tc.DecrementWithOverflow("a", 5, OverflowSaturate); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("a"); x != int8(-128) {
+		t.Errorf("got %v, expected -128", x)
+	}
+	if err := tc.DecrementWithOverflow("a", -300, OverflowSaturate); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("a"); x != int8(127) {
+		t.Errorf("got %v, expected 127", x)
+	}
+
+	tc.Set("b", uint(0), NoExpiration)
+	if err := tc.DecrementWithOverflow("b", 1, OverflowWrap); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("b"); x != uint(math.MaxUint) {
+		t.Errorf("got %v, expected %d", x, uint(math.MaxUint))
+	}
+}
+
+func TestCacheOverflowSetting(t *testing.T) {
+	tc, _ := New[string, any](Config[string, any]{Overflow: OverflowError})
+
+	tc.Set("a", int8(127), NoExpiration)
+	if err := tc.Increment("a", 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got %v, expected ErrOverflow", err)
+	}
+	if x, _ := tc.Get("a"); x != int8(127) {
+		t.Errorf("got %v, expected the value to be unchanged", x)
+	}
+	if err := tc.IncrementWithOverflow("a", 1, OverflowSaturate); err != nil {
+		t.Errorf("the mode of the call did not take precedence: %v", err)
+	}
+
+	tc.Set("b", int64(math.MaxInt64), NoExpiration)
+	if _, err := tc.IncrementInt64("b", 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got %v, expected ErrOverflow", err)
+	}
+
+	sc, _ := New[string, uint64](Config[string, uint64]{Overflow: OverflowSaturate})
+	sc.Set("c", 1, NoExpiration)
+	if v, err := sc.DecrementUint64("c", 5); err != nil || v != 0 {
+		t.Errorf("got %d, %v; expected 0", v, err)
+	}
+	if v, err := sc.IncrementOrInit("c", -1, 10, NoExpiration); err != nil || v != 0 {
+		t.Errorf("got %d, %v; expected 0", v, err)
+	}
+}
+
+func TestIncrementFloat(t *testing.T) {
+	tc, _ := NewCache()
+
+	tc.Set("a", float64(1), NoExpiration)
+	if err := tc.IncrementFloat("a", 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("a"); x != 1.5 {
+		t.Errorf("got %v, expected 1.5", x)
+	}
+	if err := tc.DecrementFloat("a", 0.25); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("a"); x != 1.25 {
+		t.Errorf("got %v, expected 1.25", x)
+	}
+
+	tc.Set("b", float32(1), NoExpiration)
+	if err := tc.IncrementFloat("b", 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("b"); x != float32(1.5) {
+		t.Errorf("got %v, expected 1.5", x)
+	}
+
+	tc.Set("c", 1, NoExpiration)
+	if err := tc.IncrementFloat("c", 0.5); err == nil {
+		t.Error("IncrementFloat accepted a value of type int")
+	}
+	if err := tc.IncrementFloat("missing", 0.5); err == nil {
+		t.Error("IncrementFloat accepted a missing key")
+	}
+}
+
+func TestIncrementFloatOverflow(t *testing.T) {
+	tc, _ := New[string, any](Config[string, any]{Overflow: OverflowSaturate})
+
+	tc.Set("a", float32(math.MaxFloat32), NoExpiration)
+	if err := tc.IncrementFloat("a", math.MaxFloat32); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := tc.Get("a"); x != float32(math.MaxFloat32) {
+		t.Errorf("got %v, expected MaxFloat32", x)
+	}
+
+	wc, _ := NewCache()
+	wc.Set("a", float32(-math.MaxFloat32), NoExpiration)
+	if err := wc.DecrementFloat("a", math.MaxFloat32); err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := wc.Get("a"); x != float32(math.Inf(-1)) {
+		t.Errorf("got %v, expected -Inf", x)
+	}
+}
@@ -0,0 +1,117 @@
+package swiftcache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// EvictionPolicy decides which key a segment evicts once it is full.
+// Every segment owns its own policy instance and calls it while holding the
+// segment's write lock, so implementations need no locking of their own,
+// except for OnAccess on policies implementing ConcurrentAccessPolicy.
+// Victim is only called right before the segment evicts the returned key,
+// so a policy may update its own state while choosing. Victim must return a
+// key the segment holds; if it returns any other key, the segment stops
+// evicting and may exceed its capacity.
+type EvictionPolicy[K comparable] interface {
+	OnInsert(key K)    // A new key was added to the segment.
+	OnAccess(key K)    // An existing key was read or updated.
+	OnRemove(key K)    // A key was removed from the segment, for whatever reason.
+	Victim() (K, bool) // The key to evict next, if the segment holds any.
+}
+
+// ConcurrentAccessPolicy is implemented by eviction policies whose OnAccess is
+// safe to call concurrently while only the segment's read lock is held. If
+// ConcurrentAccess returns true, hits take the read lock instead of the
+// segment's write lock, as they do for FIFO, SIEVE and S3-FIFO.
+type ConcurrentAccessPolicy interface {
+	ConcurrentAccess() bool
+}
+
+// ResizablePolicy is implemented by eviction policies whose internal sizes
+// depend on the capacity they were created for. With a cache-wide MaxItems,
+// a segment may borrow far more than its share of the budget, or give it
+// back; Resize is then called, with the segment's write lock held, as the
+// number of items the segment holds doubles or shrinks.
+type ResizablePolicy interface {
+	Resize(capacity int)
+}
+
+// policyFactory creates an eviction policy for a segment that holds at most capacity items.
+type policyFactory[K comparable] func(capacity int) EvictionPolicy[K]
+
+// newPolicyFactory returns the factory for the named eviction policy.
+func newPolicyFactory[K comparable](name string) (policyFactory[K], error) {
+	switch name {
+	case "LRU":
+		return func(int) EvictionPolicy[K] { return newLRUPolicy[K]() }, nil
+	case "FIFO":
+		return func(int) EvictionPolicy[K] { return newFIFOPolicy[K]() }, nil
+	case "LFU":
+		return func(int) EvictionPolicy[K] { return newLFUPolicy[K]() }, nil
+	case "TinyLFU":
+		return func(capacity int) EvictionPolicy[K] { return newTinyLFUPolicy[K](capacity) }, nil
+	case "ARC":
+		return func(capacity int) EvictionPolicy[K] { return newARCPolicy[K](capacity) }, nil
+	case "SIEVE":
+		return func(int) EvictionPolicy[K] { return newSIEVEPolicy[K]() }, nil
+	case "S3-FIFO":
+		return func(capacity int) EvictionPolicy[K] { return newS3FIFOPolicy[K](capacity) }, nil
+	default:
+		return nil, fmt.Errorf("unknown eviction policy %q", name)
+	}
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	queue *list.List          // Most recently used key at the front
+	nodes map[K]*list.Element // Position of each key in queue
+}
+
+func newLRUPolicy[K comparable]() *lruPolicy[K] {
+	return &lruPolicy[K]{
+		queue: list.New(),
+		nodes: make(map[K]*list.Element),
+	}
+}
+
+func (p *lruPolicy[K]) OnInsert(key K) {
+	p.nodes[key] = p.queue.PushFront(key)
+}
+
+func (p *lruPolicy[K]) OnAccess(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.queue.MoveToFront(node)
+	}
+}
+
+func (p *lruPolicy[K]) OnRemove(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.queue.Remove(node)
+		delete(p.nodes, key)
+	}
+}
+
+func (p *lruPolicy[K]) Victim() (K, bool) {
+	if oldest := p.queue.Back(); oldest != nil {
+		return oldest.Value.(K), true
+	}
+	var zero K
+	return zero, false
+}
+
+// fifoPolicy evicts keys in the order they were added, regardless of access.
+// Since access does not change that order, hits only need the read lock.
+type fifoPolicy[K comparable] struct {
+	lruPolicy[K]
+}
+
+func newFIFOPolicy[K comparable]() *fifoPolicy[K] {
+	return &fifoPolicy[K]{lruPolicy: *newLRUPolicy[K]()}
+}
+
+func (p *fifoPolicy[K]) OnAccess(key K) {}
+
+func (p *fifoPolicy[K]) ConcurrentAccess() bool {
+	return true
+}
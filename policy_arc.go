@@ -0,0 +1,166 @@
+package swiftcache
+
+import "container/list"
+
+// Lists of the ARC policy an entry can live in.
+const (
+	arcT1 = iota // Resident keys seen once recently
+	arcT2        // Resident keys seen at least twice recently
+	arcB1        // Ghosts of keys evicted from T1
+	arcB2        // Ghosts of keys evicted from T2
+)
+
+// arcEntry tracks a single key, resident or ghost, in the ARC policy.
+type arcEntry[K comparable] struct {
+	key  K
+	list int
+	node *list.Element
+}
+
+// arcPolicy implements the Adaptive Replacement Cache. Resident keys live in
+// T1 (recency) or T2 (frequency); the ghost lists B1 and B2 remember keys
+// recently evicted from them. A miss that hits a ghost shows which side was
+// too small, and the target size p of T1 adapts accordingly, so each segment
+// tunes itself between recency- and frequency-heavy workloads.
+type arcPolicy[K comparable] struct {
+	entries  map[K]*arcEntry[K]
+	lists    [4]*list.List // Indexed by arcT1..arcB2, most recently used at the front
+	capacity int
+	p        int          // Target size of T1
+	hitB2    bool         // Whether the latest insert was a ghost hit in B2
+	newest   *arcEntry[K] // The latest inserted key, never the victim while others remain
+	evicting *arcEntry[K] // The key returned by victim, to be turned into a ghost
+}
+
+func newARCPolicy[K comparable](capacity int) *arcPolicy[K] {
+	p := &arcPolicy[K]{
+		entries:  make(map[K]*arcEntry[K]),
+		capacity: capacity,
+	}
+	for i := range p.lists {
+		p.lists[i] = list.New()
+	}
+	return p
+}
+
+// Resize changes the capacity the policy tunes itself for, forgetting ghosts
+// beyond the new bounds.
+func (p *arcPolicy[K]) Resize(capacity int) {
+	p.capacity = capacity
+	p.p = min(p.p, capacity)
+	for p.lists[arcB1].Len() > 0 && p.lists[arcT1].Len()+p.lists[arcB1].Len() > capacity {
+		p.dropLRU(arcB1)
+	}
+	for p.lists[arcB2].Len() > 0 && len(p.entries) > 2*capacity {
+		p.dropLRU(arcB2)
+	}
+}
+
+// move puts entry at the front of the given list.
+func (p *arcPolicy[K]) move(entry *arcEntry[K], to int) {
+	p.lists[entry.list].Remove(entry.node)
+	entry.list = to
+	entry.node = p.lists[to].PushFront(entry)
+}
+
+// dropLRU forgets the least recently used ghost of the given list.
+func (p *arcPolicy[K]) dropLRU(ghosts int) {
+	if back := p.lists[ghosts].Back(); back != nil {
+		entry := p.lists[ghosts].Remove(back).(*arcEntry[K])
+		delete(p.entries, entry.key)
+	}
+}
+
+func (p *arcPolicy[K]) OnInsert(key K) {
+	t1, t2, b1, b2 := p.lists[arcT1].Len(), p.lists[arcT2].Len(), p.lists[arcB1].Len(), p.lists[arcB2].Len()
+	p.hitB2 = false
+
+	if entry, ok := p.entries[key]; ok {
+		switch entry.list {
+		case arcB1:
+			// T1 was too small: grow its target
+			p.p = min(p.capacity, p.p+max(b2/b1, 1))
+		case arcB2:
+			// T2 was too small: shrink the target of T1
+			p.p = max(0, p.p-max(b1/b2, 1))
+			p.hitB2 = true
+		}
+		p.move(entry, arcT2)
+		p.newest = entry
+		return
+	}
+
+	// Keep the ghost lists bounded: T1+B1 and the whole directory hold at most c and 2c keys
+	if t1+b1 >= p.capacity && b1 > 0 {
+		p.dropLRU(arcB1)
+	} else if t1+t2+b1+b2 >= 2*p.capacity && b2 > 0 {
+		p.dropLRU(arcB2)
+	}
+
+	entry := &arcEntry[K]{key: key, list: arcT1}
+	entry.node = p.lists[arcT1].PushFront(entry)
+	p.entries[key] = entry
+	p.newest = entry
+}
+
+func (p *arcPolicy[K]) OnAccess(key K) {
+	if entry, ok := p.entries[key]; ok && (entry.list == arcT1 || entry.list == arcT2) {
+		p.move(entry, arcT2)
+	}
+}
+
+func (p *arcPolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.list == arcB1 || entry.list == arcB2 {
+		return
+	}
+	if entry == p.newest {
+		p.newest = nil
+	}
+
+	// Evicted keys are remembered as ghosts; deleted or expired keys are simply forgotten
+	if entry == p.evicting {
+		p.evicting = nil
+		if entry.list == arcT1 {
+			p.move(entry, arcB1)
+		} else {
+			p.move(entry, arcB2)
+		}
+		return
+	}
+	p.lists[entry.list].Remove(entry.node)
+	delete(p.entries, key)
+}
+
+// Victim implements ARC's REPLACE: evict from T1 while it is larger than its
+// target p, and from T2 otherwise.
+func (p *arcPolicy[K]) Victim() (K, bool) {
+	t1 := p.lists[arcT1].Len()
+	fromT1 := t1 > 0 && (t1 > p.p || (p.hitB2 && t1 == p.p))
+	p.hitB2 = false
+
+	first, second := arcT2, arcT1
+	if fromT1 {
+		first, second = arcT1, arcT2
+	}
+	for _, from := range [...]int{first, second} {
+		back := p.lists[from].Back()
+		if back == nil {
+			continue
+		}
+		entry := back.Value.(*arcEntry[K])
+		if entry == p.newest && p.lists[arcT1].Len()+p.lists[arcT2].Len() > 1 {
+			// Replacement happens on behalf of the newest key, so it must not evict itself
+			if prev := back.Prev(); prev != nil {
+				entry = prev.Value.(*arcEntry[K])
+			} else {
+				continue
+			}
+		}
+		p.evicting = entry
+		return entry.key, true
+	}
+
+	var zero K
+	return zero, false
+}
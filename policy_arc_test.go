@@ -0,0 +1,73 @@
+package swiftcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCacheARCSurvivesScan(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   10,
+		EvictionPolicy: "ARC",
+	})
+
+	// Keys used twice move to T2
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		tc.Set(key, i, NoExpiration)
+		tc.Get(key)
+	}
+
+	// A scan of keys that are only ever touched once stays in T1
+	for i := 0; i < 100; i++ {
+		tc.Set(fmt.Sprintf("scan%d", i), i, NoExpiration)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, found := tc.Get(fmt.Sprintf("hot%d", i)); !found {
+			t.Errorf("hot%d was flushed out by the scan", i)
+		}
+	}
+	if n := tc.ItemCount(); n != 10 {
+		t.Errorf("Item count is not 10: %d", n)
+	}
+}
+
+func TestARCPolicyAdapts(t *testing.T) {
+	p := newARCPolicy[string](2)
+	evict := func() string {
+		key, ok := p.Victim()
+		if !ok {
+			t.Fatal("victim found nothing to evict")
+		}
+		p.OnRemove(key)
+		return key
+	}
+
+	p.OnInsert("a")
+	p.OnInsert("b")
+	p.OnInsert("c")
+	if key := evict(); key != "a" {
+		t.Fatalf("evicted %s, expected a", key)
+	}
+	if entry := p.entries["a"]; entry == nil || entry.list != arcB1 {
+		t.Fatal("a was not remembered in B1")
+	}
+
+	// A ghost hit in B1 grows the target size of T1 and brings the key back into T2
+	p.OnInsert("a")
+	if p.p != 1 {
+		t.Errorf("p is %d after a B1 ghost hit, expected 1", p.p)
+	}
+	if p.entries["a"].list != arcT2 {
+		t.Error("a did not move to T2 after a ghost hit")
+	}
+	evict()
+
+	// Explicitly removed keys do not become ghosts
+	p.OnRemove("a")
+	if _, ok := p.entries["a"]; ok {
+		t.Error("a was remembered after being removed explicitly")
+	}
+}
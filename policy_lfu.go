@@ -0,0 +1,148 @@
+package swiftcache
+
+import "container/list"
+
+// lfuAgingFactor controls how often LFU frequencies are aged: once the number
+// of accesses since the last aging reaches lfuAgingFactor times the number of
+// tracked keys, every frequency is halved.
+const lfuAgingFactor = 10
+
+// lfuBucket holds all keys that share the same access frequency.
+type lfuBucket[K comparable] struct {
+	freq    int
+	entries *list.List // *lfuEntry values, least recently used at the front
+}
+
+// lfuEntry tracks a single key in the LFU policy.
+type lfuEntry[K comparable] struct {
+	key    K
+	bucket *list.Element // Element of lfuPolicy.buckets holding this entry
+	node   *list.Element // Element of the bucket's entries list
+}
+
+// lfuPolicy evicts the least frequently used key, breaking ties by recency.
+// It uses the O(1) LFU scheme: an ascending list of frequency buckets, each
+// holding its keys in access order, so insert, access, remove and victim are
+// all constant time. Frequencies are periodically halved so keys that were
+// hot long ago do not stay in the cache forever.
+type lfuPolicy[K comparable] struct {
+	entries  map[K]*lfuEntry[K]
+	buckets  *list.List   // *lfuBucket values in ascending frequency order
+	accesses int          // Accesses since the last aging
+	newest   *lfuEntry[K] // The latest inserted key until it is read, never the victim while others remain
+}
+
+func newLFUPolicy[K comparable]() *lfuPolicy[K] {
+	return &lfuPolicy[K]{
+		entries: make(map[K]*lfuEntry[K]),
+		buckets: list.New(),
+	}
+}
+
+func (p *lfuPolicy[K]) OnInsert(key K) {
+	entry := &lfuEntry[K]{key: key}
+	p.entries[key] = entry
+	p.newest = entry
+
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket[K]).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket[K]{freq: 1, entries: list.New()})
+	}
+	p.place(entry, front)
+}
+
+func (p *lfuPolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	if entry == p.newest {
+		p.newest = nil // Once read, the key competes like any other
+	}
+
+	current := entry.bucket
+	freq := current.Value.(*lfuBucket[K]).freq + 1
+	next := current.Next()
+	if next == nil || next.Value.(*lfuBucket[K]).freq != freq {
+		next = p.buckets.InsertAfter(&lfuBucket[K]{freq: freq, entries: list.New()}, current)
+	}
+	p.unplace(entry)
+	p.place(entry, next)
+
+	p.accesses++
+	if p.accesses >= lfuAgingFactor*len(p.entries) {
+		p.age()
+	}
+}
+
+func (p *lfuPolicy[K]) OnRemove(key K) {
+	if entry, ok := p.entries[key]; ok {
+		if entry == p.newest {
+			p.newest = nil
+		}
+		p.unplace(entry)
+		delete(p.entries, key)
+	}
+}
+
+// Victim returns the least recently used key of the lowest frequency. A new
+// key enters with the lowest frequency, so it would be its own victim as soon
+// as all other keys were read; eviction happens on its behalf, so it is
+// skipped in favor of the next candidate unless it is the only key left.
+func (p *lfuPolicy[K]) Victim() (K, bool) {
+	front := p.buckets.Front()
+	if front == nil {
+		var zero K
+		return zero, false
+	}
+
+	victim := front.Value.(*lfuBucket[K]).entries.Front()
+	if victim.Value.(*lfuEntry[K]) == p.newest {
+		if next := victim.Next(); next != nil {
+			victim = next
+		} else if bucket := front.Next(); bucket != nil {
+			victim = bucket.Value.(*lfuBucket[K]).entries.Front()
+		}
+	}
+	return victim.Value.(*lfuEntry[K]).key, true
+}
+
+// place appends entry to the bucket held by element b.
+func (p *lfuPolicy[K]) place(entry *lfuEntry[K], b *list.Element) {
+	entry.bucket = b
+	entry.node = b.Value.(*lfuBucket[K]).entries.PushBack(entry)
+}
+
+// unplace removes entry from its bucket, dropping the bucket once it is empty.
+func (p *lfuPolicy[K]) unplace(entry *lfuEntry[K]) {
+	bucket := entry.bucket.Value.(*lfuBucket[K])
+	bucket.entries.Remove(entry.node)
+	if bucket.entries.Len() == 0 {
+		p.buckets.Remove(entry.bucket)
+	}
+}
+
+// age halves every frequency (keeping a minimum of 1). Halving preserves the
+// order of frequencies, so the bucket list is rebuilt in a single pass and the
+// O(n) cost is amortized over the lfuAgingFactor*n accesses between agings.
+func (p *lfuPolicy[K]) age() {
+	p.accesses = 0
+
+	old := p.buckets
+	p.buckets = list.New()
+	for b := old.Front(); b != nil; b = b.Next() {
+		bucket := b.Value.(*lfuBucket[K])
+		freq := bucket.freq / 2
+		if freq < 1 {
+			freq = 1
+		}
+
+		back := p.buckets.Back()
+		if back == nil || back.Value.(*lfuBucket[K]).freq != freq {
+			back = p.buckets.PushBack(&lfuBucket[K]{freq: freq, entries: list.New()})
+		}
+		for e := bucket.entries.Front(); e != nil; e = e.Next() {
+			p.place(e.Value.(*lfuEntry[K]), back)
+		}
+	}
+}
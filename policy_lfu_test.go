@@ -0,0 +1,135 @@
+package swiftcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCacheLFUEviction(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   3,
+		EvictionPolicy: "LFU",
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, NoExpiration)
+	tc.Set("c", 3, NoExpiration)
+	tc.Get("a")
+	tc.Get("a")
+	tc.Get("b")
+
+	// c has the lowest frequency and must make room for d
+	tc.Set("d", 4, NoExpiration)
+	if _, found := tc.Get("c"); found {
+		t.Error("LFU eviction failed: c should have been evicted")
+	}
+	for _, key := range []string{"a", "b", "d"} {
+		if _, found := tc.Get(key); !found {
+			t.Errorf("LFU eviction failed: %s should still be cached", key)
+		}
+	}
+}
+
+func TestCacheLFUSurvivesScan(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   10,
+		EvictionPolicy: "LFU",
+	})
+
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		tc.Set(key, i, NoExpiration)
+		for j := 0; j < 5; j++ {
+			tc.Get(key)
+		}
+	}
+
+	// A scan of keys that are only ever touched once
+	for i := 0; i < 100; i++ {
+		tc.Set(fmt.Sprintf("scan%d", i), i, NoExpiration)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, found := tc.Get(fmt.Sprintf("hot%d", i)); !found {
+			t.Errorf("hot%d was flushed out by the scan", i)
+		}
+	}
+}
+
+func TestLFUPolicyAging(t *testing.T) {
+	p := newLFUPolicy[string]()
+	p.OnInsert("a")
+	p.OnInsert("b")
+	for i := 0; i < 7; i++ {
+		p.OnAccess("a") // a reaches frequency 8
+	}
+	p.OnAccess("b") // b reaches frequency 2
+
+	p.age()
+
+	var freqs []int
+	for b := p.buckets.Front(); b != nil; b = b.Next() {
+		freqs = append(freqs, b.Value.(*lfuBucket[string]).freq)
+	}
+	if fmt.Sprint(freqs) != "[1 4]" {
+		t.Errorf("frequencies after aging are %v, expected [1 4]", freqs)
+	}
+	if key, _ := p.Victim(); key != "b" {
+		t.Errorf("victim after aging is %s, expected b", key)
+	}
+
+	// Aging also happens on its own after enough accesses
+	for i := 0; i < lfuAgingFactor*len(p.entries); i++ {
+		p.OnAccess("b")
+	}
+	if p.accesses != 0 {
+		t.Errorf("accesses is %d, expected aging to reset it", p.accesses)
+	}
+}
+
+func TestCacheLFUKeepsNewKey(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   2,
+		EvictionPolicy: "LFU",
+	})
+
+	var evicted []string
+	tc.OnEvicted(func(k string, v interface{}) {
+		evicted = append(evicted, k)
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, NoExpiration)
+	tc.Get("a")
+	tc.Get("b")
+
+	// c is the only key with the lowest frequency, but it was just written
+	tc.Set("c", 3, NoExpiration)
+	if _, found := tc.Get("c"); !found {
+		t.Error("LFU evicted the key it was inserting")
+	}
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Errorf("got %v evicted, expected a", evicted)
+	}
+}
+
+func TestCacheLFUReplacesReadKey(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   1,
+		EvictionPolicy: "LFU",
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Get("a")
+	tc.Set("b", 2, NoExpiration)
+	if _, found := tc.Get("b"); !found {
+		t.Error("a key that was read once could not be replaced")
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("a was not evicted")
+	}
+}
@@ -0,0 +1,251 @@
+package swiftcache
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+// sieveNode tracks a single key in the SIEVE policy.
+type sieveNode[K comparable] struct {
+	key     K
+	visited atomic.Bool // Set on hit, possibly by many readers at once
+}
+
+// sievePolicy implements SIEVE. Keys are kept in insertion order and a hit
+// only sets the key's visited bit, so hits need nothing but the read lock.
+// On eviction a hand sweeps from the oldest key towards the newest, clearing
+// visited bits, and evicts the first key that was not visited since the hand
+// last passed it.
+type sievePolicy[K comparable] struct {
+	queue *list.List // *sieveNode values, newest at the front
+	nodes map[K]*list.Element
+	hand  *list.Element // Next element the hand inspects, nil means the back
+}
+
+func newSIEVEPolicy[K comparable]() *sievePolicy[K] {
+	return &sievePolicy[K]{
+		queue: list.New(),
+		nodes: make(map[K]*list.Element),
+	}
+}
+
+func (p *sievePolicy[K]) OnInsert(key K) {
+	p.nodes[key] = p.queue.PushFront(&sieveNode[K]{key: key})
+}
+
+func (p *sievePolicy[K]) OnAccess(key K) {
+	if e, ok := p.nodes[key]; ok {
+		if node := e.Value.(*sieveNode[K]); !node.visited.Load() {
+			node.visited.Store(true)
+		}
+	}
+}
+
+func (p *sievePolicy[K]) OnRemove(key K) {
+	e, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+	if e == p.hand {
+		p.hand = e.Prev()
+	}
+	p.queue.Remove(e)
+	delete(p.nodes, key)
+}
+
+func (p *sievePolicy[K]) Victim() (K, bool) {
+	if p.queue.Len() == 0 {
+		var zero K
+		return zero, false
+	}
+
+	e := p.hand
+	for {
+		if e == nil {
+			e = p.queue.Back()
+		}
+		node := e.Value.(*sieveNode[K])
+		// The newest key is the one being inserted, so it is skipped while others remain
+		if !node.visited.Load() && (e != p.queue.Front() || p.queue.Len() == 1) {
+			p.hand = e
+			return node.key, true
+		}
+		node.visited.Store(false)
+		e = e.Prev()
+	}
+}
+
+func (p *sievePolicy[K]) ConcurrentAccess() bool {
+	return true
+}
+
+const (
+	s3FIFOSmallRatio = 0.1 // Share of the capacity given to the small queue
+	s3FIFOMaxFreq    = 3   // Access counters saturate at 3
+)
+
+// Queues of the S3-FIFO policy an entry can live in.
+const (
+	s3FIFOSmall = iota
+	s3FIFOMain
+	s3FIFOGhost
+)
+
+// s3FIFOEntry tracks a single key, resident or ghost, in the S3-FIFO policy.
+type s3FIFOEntry[K comparable] struct {
+	key   K
+	queue int
+	node  *list.Element
+	freq  atomic.Int32 // Incremented on hit, possibly by many readers at once
+}
+
+// s3FIFOPolicy implements S3-FIFO with three FIFO queues: a small queue that
+// new keys enter, a main queue, and a ghost queue of keys recently evicted
+// from the small queue. Keys hit at least twice while in the small queue are
+// moved to main, so one-hit wonders leave quickly. The main queue evicts
+// like CLOCK, reinserting keys that were hit. A key found in the ghost queue
+// goes directly to main. Hits only increment an atomic counter, so they need
+// nothing but the read lock.
+type s3FIFOPolicy[K comparable] struct {
+	entries  map[K]*s3FIFOEntry[K]
+	queues   [3]*list.List // Indexed by s3FIFOSmall..s3FIFOGhost, newest at the front
+	smallMax int
+	ghostMax int
+	newest   *s3FIFOEntry[K] // The latest inserted key, never the victim while others remain
+	evicting *s3FIFOEntry[K] // The key returned by victim, to be turned into a ghost
+}
+
+func newS3FIFOPolicy[K comparable](capacity int) *s3FIFOPolicy[K] {
+	p := &s3FIFOPolicy[K]{
+		entries: make(map[K]*s3FIFOEntry[K]),
+	}
+	for i := range p.queues {
+		p.queues[i] = list.New()
+	}
+	p.Resize(capacity)
+	return p
+}
+
+// Resize sizes the small and ghost queues for capacity keys, forgetting the
+// oldest ghosts beyond the new bound. The small queue shrinks as keys leave it.
+func (p *s3FIFOPolicy[K]) Resize(capacity int) {
+	p.smallMax = int(float64(capacity) * s3FIFOSmallRatio)
+	if p.smallMax < 1 {
+		p.smallMax = 1
+	}
+	p.ghostMax = capacity - p.smallMax
+	for ghosts := p.queues[s3FIFOGhost]; ghosts.Len() > p.ghostMax; {
+		oldest := ghosts.Remove(ghosts.Back()).(*s3FIFOEntry[K])
+		delete(p.entries, oldest.key)
+	}
+}
+
+// move puts entry at the front of the given queue.
+func (p *s3FIFOPolicy[K]) move(entry *s3FIFOEntry[K], to int) {
+	p.queues[entry.queue].Remove(entry.node)
+	entry.queue = to
+	entry.node = p.queues[to].PushFront(entry)
+}
+
+func (p *s3FIFOPolicy[K]) OnInsert(key K) {
+	if entry, ok := p.entries[key]; ok {
+		// Evicted from small not long ago: it deserves the main queue
+		entry.freq.Store(0)
+		p.move(entry, s3FIFOMain)
+		p.newest = entry
+		return
+	}
+
+	entry := &s3FIFOEntry[K]{key: key, queue: s3FIFOSmall}
+	entry.node = p.queues[s3FIFOSmall].PushFront(entry)
+	p.entries[key] = entry
+	p.newest = entry
+}
+
+func (p *s3FIFOPolicy[K]) OnAccess(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.queue == s3FIFOGhost {
+		return
+	}
+	for {
+		freq := entry.freq.Load()
+		if freq >= s3FIFOMaxFreq || entry.freq.CompareAndSwap(freq, freq+1) {
+			return
+		}
+	}
+}
+
+func (p *s3FIFOPolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok || entry.queue == s3FIFOGhost {
+		return
+	}
+	if entry == p.newest {
+		p.newest = nil
+	}
+
+	// Keys evicted from small are remembered as ghosts; anything else is forgotten
+	if entry == p.evicting {
+		p.evicting = nil
+		if entry.queue == s3FIFOSmall {
+			p.move(entry, s3FIFOGhost)
+			if p.queues[s3FIFOGhost].Len() > p.ghostMax {
+				oldest := p.queues[s3FIFOGhost].Remove(p.queues[s3FIFOGhost].Back()).(*s3FIFOEntry[K])
+				delete(p.entries, oldest.key)
+			}
+			return
+		}
+	}
+	p.queues[entry.queue].Remove(entry.node)
+	delete(p.entries, key)
+}
+
+// oldest returns the oldest entry of a resident queue, skipping the newest key.
+func (p *s3FIFOPolicy[K]) oldest(queue int) *s3FIFOEntry[K] {
+	back := p.queues[queue].Back()
+	if back == nil {
+		return nil
+	}
+	if entry := back.Value.(*s3FIFOEntry[K]); entry != p.newest {
+		return entry
+	}
+	if prev := back.Prev(); prev != nil {
+		return prev.Value.(*s3FIFOEntry[K])
+	}
+	return nil
+}
+
+func (p *s3FIFOPolicy[K]) Victim() (K, bool) {
+	for {
+		small, main := p.oldest(s3FIFOSmall), p.oldest(s3FIFOMain)
+
+		switch {
+		case small != nil && (p.queues[s3FIFOSmall].Len() > p.smallMax || main == nil):
+			if small.freq.Load() > 1 {
+				small.freq.Store(0)
+				p.move(small, s3FIFOMain)
+				continue
+			}
+			p.evicting = small
+			return small.key, true
+		case main != nil:
+			if freq := main.freq.Load(); freq > 0 {
+				main.freq.Store(freq - 1)
+				p.move(main, s3FIFOMain)
+				continue
+			}
+			p.evicting = main
+			return main.key, true
+		case p.newest != nil:
+			p.evicting = p.newest
+			return p.newest.key, true
+		default:
+			var zero K
+			return zero, false
+		}
+	}
+}
+
+func (p *s3FIFOPolicy[K]) ConcurrentAccess() bool {
+	return true
+}
@@ -0,0 +1,147 @@
+package swiftcache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCacheSIEVEEviction(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   3,
+		EvictionPolicy: "SIEVE",
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, NoExpiration)
+	tc.Set("c", 3, NoExpiration)
+	tc.Get("a")
+
+	// The hand passes a, which was visited, and evicts b
+	tc.Set("d", 4, NoExpiration)
+	if _, found := tc.Get("b"); found {
+		t.Error("SIEVE eviction failed: b should have been evicted")
+	}
+
+	// The hand continues at c, which was not visited
+	tc.Set("e", 5, NoExpiration)
+	if _, found := tc.Get("c"); found {
+		t.Error("SIEVE eviction failed: c should have been evicted")
+	}
+	for _, key := range []string{"a", "d", "e"} {
+		if _, found := tc.Get(key); !found {
+			t.Errorf("SIEVE eviction failed: %s should still be cached", key)
+		}
+	}
+}
+
+func TestCacheS3FIFOSurvivesScan(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   20,
+		EvictionPolicy: "S3-FIFO",
+	})
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		tc.Set(key, i, NoExpiration)
+		tc.Get(key)
+		tc.Get(key)
+	}
+
+	// A scan of keys that are only ever touched once is evicted from the small queue
+	for i := 0; i < 200; i++ {
+		tc.Set(fmt.Sprintf("scan%d", i), i, NoExpiration)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, found := tc.Get(fmt.Sprintf("hot%d", i)); !found {
+			t.Errorf("hot%d was flushed out by the scan", i)
+		}
+	}
+	if n := tc.ItemCount(); n != 20 {
+		t.Errorf("Item count is not 20: %d", n)
+	}
+}
+
+func TestS3FIFOGhostHit(t *testing.T) {
+	p := newS3FIFOPolicy[string](10)
+	p.OnInsert("a")
+	p.OnInsert("b")
+	key, _ := p.Victim()
+	if key != "a" {
+		t.Fatalf("evicted %s, expected a", key)
+	}
+	p.OnRemove(key)
+	if entry := p.entries["a"]; entry == nil || entry.queue != s3FIFOGhost {
+		t.Fatal("a was not remembered in the ghost queue")
+	}
+
+	p.OnInsert("a")
+	if p.entries["a"].queue != s3FIFOMain {
+		t.Error("a did not go to the main queue after a ghost hit")
+	}
+}
+
+func TestCacheConcurrentAccessPolicies(t *testing.T) {
+	for _, policy := range []string{"FIFO", "SIEVE", "S3-FIFO"} {
+		tc, _ := NewCache(CacheConfig{
+			SegmentCount:   4,
+			MaxCacheSize:   50,
+			EvictionPolicy: policy,
+		})
+		if !tc.segments[0].concurrentAccess {
+			t.Errorf("%s hits take the write lock", policy)
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					key := fmt.Sprintf("key%d", (i*7+w)%300)
+					if i%4 == 0 {
+						tc.Set(key, i, NoExpiration)
+					} else {
+						tc.Get(key)
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		if n := tc.ItemCount(); n > 4*50 {
+			t.Errorf("%s: item count %d exceeds the capacity", policy, n)
+		}
+	}
+}
+
+func TestCacheConcurrentAccessFlush(t *testing.T) {
+	for _, policy := range []string{"FIFO", "SIEVE"} {
+		tc, _ := NewCache(CacheConfig{SegmentCount: 2, EvictionPolicy: policy})
+		keys := []string{"a", "b", "c"}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					tc.Set(keys[i%3], i, NoExpiration)
+					tc.Get(keys[i%3])
+					tc.GetMany(keys)
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			tc.Flush()
+		}
+		wg.Wait()
+
+		if !tc.segments[0].concurrentAccess {
+			t.Errorf("%s hits take the write lock after Flush", policy)
+		}
+	}
+}
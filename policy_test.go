@@ -0,0 +1,141 @@
+package swiftcache
+
+import (
+	"container/list"
+	"testing"
+	"time"
+)
+
+// mruPolicy evicts the most recently used key other than the newest one.
+// It is only used to test custom policies.
+type mruPolicy struct {
+	queue  *list.List // Most recently used at the front
+	nodes  map[string]*list.Element
+	events []string
+}
+
+func newMRUPolicy(int) EvictionPolicy[string] {
+	return &mruPolicy{queue: list.New(), nodes: make(map[string]*list.Element)}
+}
+
+func (p *mruPolicy) OnInsert(key string) {
+	p.events = append(p.events, "insert "+key)
+	p.nodes[key] = p.queue.PushFront(key)
+}
+
+func (p *mruPolicy) OnAccess(key string) {
+	p.events = append(p.events, "access "+key)
+	p.queue.MoveToFront(p.nodes[key])
+}
+
+func (p *mruPolicy) OnRemove(key string) {
+	p.events = append(p.events, "remove "+key)
+	p.queue.Remove(p.nodes[key])
+	delete(p.nodes, key)
+}
+
+func (p *mruPolicy) Victim() (string, bool) {
+	if front := p.queue.Front(); front != nil && front.Next() != nil {
+		return front.Next().Value.(string), true
+	}
+	return "", false
+}
+
+func TestCustomEvictionPolicy(t *testing.T) {
+	tc, err := NewCache(CacheConfig{
+		SegmentCount:      1,
+		MaxCacheSize:      3,
+		EvictionPolicy:    "no such policy", // Ignored, NewEvictionPolicy takes precedence
+		NewEvictionPolicy: newMRUPolicy,
+	})
+	if err != nil {
+		t.Fatal("Error creating cache with a custom policy:", err)
+	}
+
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, NoExpiration)
+	tc.Set("c", 3, NoExpiration)
+	tc.Get("a")
+
+	// a is the most recently used key, so it makes room for d
+	tc.Set("d", 4, NoExpiration)
+	if _, found := tc.Get("a"); found {
+		t.Error("custom policy was not used: a should have been evicted")
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, found := tc.Get(key); !found {
+			t.Errorf("custom policy was not used: %s should still be cached", key)
+		}
+	}
+
+	p := tc.segments[0].policy.(*mruPolicy)
+	expected := []string{"insert a", "insert b", "insert c", "access a", "insert d", "remove a"}
+	for i, event := range expected {
+		if i >= len(p.events) || p.events[i] != event {
+			t.Fatalf("policy events are %v, expected them to start with %v", p.events, expected)
+		}
+	}
+	if tc.segments[0].concurrentAccess {
+		t.Error("hits on a policy without ConcurrentAccess take the read lock")
+	}
+}
+
+type concurrentMRUPolicy struct {
+	mruPolicy
+}
+
+func (p *concurrentMRUPolicy) OnAccess(key string) {}
+
+func (p *concurrentMRUPolicy) ConcurrentAccess() bool {
+	return true
+}
+
+func TestCustomConcurrentAccessPolicy(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount: 1,
+		NewEvictionPolicy: func(int) EvictionPolicy[string] {
+			return &concurrentMRUPolicy{mruPolicy{queue: list.New(), nodes: make(map[string]*list.Element)}}
+		},
+	})
+	if !tc.segments[0].concurrentAccess {
+		t.Error("hits on a policy with ConcurrentAccess take the write lock")
+	}
+}
+
+// strayPolicy names a key no segment holds as its victim.
+type strayPolicy struct{}
+
+func (strayPolicy) OnInsert(key string) {}
+func (strayPolicy) OnAccess(key string) {}
+func (strayPolicy) OnRemove(key string) {}
+
+func (strayPolicy) Victim() (string, bool) {
+	return "nope", true
+}
+
+func TestCustomPolicyStrayVictim(t *testing.T) {
+	newStrayPolicy := func(int) EvictionPolicy[string] { return strayPolicy{} }
+	configs := map[string]CacheConfig{
+		"MaxCacheSize": {SegmentCount: 1, MaxCacheSize: 1, NewEvictionPolicy: newStrayPolicy},
+		"MaxItems":     {SegmentCount: 4, MaxItems: 1, NewEvictionPolicy: newStrayPolicy},
+	}
+	for name, config := range configs {
+		tc, _ := NewCache(config)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			tc.Set("a", 1, NoExpiration)
+			tc.Set("b", 2, NoExpiration)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: Set hangs when the policy names a key the segment does not hold", name)
+		}
+
+		if _, found := tc.Get("b"); !found {
+			t.Errorf("%s: b was not set", name)
+		}
+	}
+}
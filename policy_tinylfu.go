@@ -0,0 +1,287 @@
+package swiftcache
+
+import (
+	"container/list"
+	"hash"
+	"hash/fnv"
+)
+
+const (
+	sketchDepth           = 4   // Number of rows, i.e. hash functions, of the count-min sketch
+	sketchWidthFactor     = 4   // Counters per row for every key the segment can hold
+	sketchMaxCount        = 15  // Counters saturate at 15, as 4-bit counters would
+	sketchResetFactor     = 10  // The sketch is halved after sketchResetFactor*capacity increments
+	tinyLFUWindowRatio    = 100 // The window holds 1/tinyLFUWindowRatio of the capacity, at least one key
+	tinyLFUProtectedRatio = 0.8 // Share of the main space reserved for the protected segment
+)
+
+// countMinSketch estimates how often keys were seen, using sketchDepth rows of
+// small saturating counters. All counters are halved periodically, so the
+// estimates reflect recent popularity rather than all-time popularity.
+type countMinSketch[K comparable] struct {
+	rows      [sketchDepth][]uint8
+	mask      uint32
+	additions int
+	resetAt   int
+	hasher    hash.Hash64
+}
+
+// newCountMinSketch creates a sketch sized for about capacity distinct keys.
+func newCountMinSketch[K comparable](capacity int) *countMinSketch[K] {
+	s := &countMinSketch[K]{hasher: fnv.New64a()}
+	s.resize(capacity)
+	return s
+}
+
+// sketchWidth returns the number of counters per row for capacity keys.
+func sketchWidth(capacity int) int {
+	width := 16
+	for width < sketchWidthFactor*capacity {
+		width <<= 1
+	}
+	return width
+}
+
+// resize sizes the sketch for about capacity distinct keys, keeping the
+// estimates. Widths are powers of two, so a key's counter in a row of the
+// new width maps to the counter at the same index masked to the old width:
+// growing copies counters over, shrinking keeps the largest of those folded
+// together, which can only overestimate, as count-min sketches do anyway.
+func (s *countMinSketch[K]) resize(capacity int) {
+	s.resetAt = sketchResetFactor * max(capacity, 1)
+	width := sketchWidth(capacity)
+	if s.rows[0] != nil && width == len(s.rows[0]) {
+		return
+	}
+
+	for i, old := range s.rows {
+		row := make([]uint8, width)
+		if len(old) > 0 {
+			mask := len(old) - 1
+			for j := range row {
+				row[j] = old[j&mask]
+			}
+			for j := width; j < len(old); j++ {
+				row[j&(width-1)] = max(row[j&(width-1)], old[j])
+			}
+		}
+		s.rows[i] = row
+	}
+	s.mask = uint32(width - 1)
+}
+
+// indexes returns the counter positions of key, one per row, using double hashing.
+func (s *countMinSketch[K]) indexes(key K) [sketchDepth]uint32 {
+	s.hasher.Reset()
+	_ = writeKey(s.hasher, key) // Writing to a hash never fails
+	sum := s.hasher.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)|1
+
+	var idx [sketchDepth]uint32
+	for i := range idx {
+		idx[i] = (h1 + uint32(i)*h2) & s.mask
+	}
+	return idx
+}
+
+// increment records one occurrence of key.
+func (s *countMinSketch[K]) increment(key K) {
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < sketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+// estimate returns the estimated number of occurrences of key.
+func (s *countMinSketch[K]) estimate(key K) uint8 {
+	count := uint8(sketchMaxCount)
+	for i, idx := range s.indexes(key) {
+		if c := s.rows[i][idx]; c < count {
+			count = c
+		}
+	}
+	return count
+}
+
+// reset halves every counter so old popularity fades away.
+func (s *countMinSketch[K]) reset() {
+	for _, row := range s.rows {
+		for i := range row {
+			row[i] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// Regions of the W-TinyLFU policy an entry can live in.
+const (
+	tinyLFUWindow = iota
+	tinyLFUProbation
+	tinyLFUProtected
+)
+
+// tinyLFUEntry tracks a single key in the W-TinyLFU policy.
+type tinyLFUEntry[K comparable] struct {
+	key    K
+	region int
+	node   *list.Element
+}
+
+// tinyLFUPolicy implements W-TinyLFU. New keys enter a small window LRU.
+// Keys falling out of the window become candidates for the main space, a
+// segmented LRU made of a probation and a protected part. When the segment
+// is full, a candidate only displaces the main space's victim if the
+// count-min sketch estimates it to be used more often; otherwise the
+// candidate itself is evicted. Hits in probation promote keys to protected.
+type tinyLFUPolicy[K comparable] struct {
+	entries      map[K]*tinyLFUEntry[K]
+	window       *list.List // Most recently used at the front
+	probation    *list.List
+	protected    *list.List
+	windowMax    int
+	protectedMax int
+	candidate    *tinyLFUEntry[K] // Latest key moved from the window to probation, if not yet admitted
+	sketch       *countMinSketch[K]
+}
+
+func newTinyLFUPolicy[K comparable](capacity int) *tinyLFUPolicy[K] {
+	p := &tinyLFUPolicy[K]{
+		entries:   make(map[K]*tinyLFUEntry[K]),
+		window:    list.New(),
+		probation: list.New(),
+		protected: list.New(),
+		sketch:    newCountMinSketch[K](capacity),
+	}
+	p.setLimits(capacity)
+	return p
+}
+
+// setLimits sizes the window and the protected segment for capacity keys.
+func (p *tinyLFUPolicy[K]) setLimits(capacity int) {
+	p.windowMax = capacity / tinyLFUWindowRatio
+	if p.windowMax < 1 {
+		p.windowMax = 1
+	}
+	p.protectedMax = int(float64(capacity-p.windowMax) * tinyLFUProtectedRatio)
+}
+
+// Resize sizes the window, the protected segment and the sketch for capacity
+// keys. Keys beyond the new limits move on to probation, as they would have
+// with those limits in place.
+func (p *tinyLFUPolicy[K]) Resize(capacity int) {
+	p.setLimits(capacity)
+	p.sketch.resize(capacity)
+	for p.window.Len() > p.windowMax {
+		p.move(p.window.Back().Value.(*tinyLFUEntry[K]), tinyLFUProbation)
+	}
+	for p.protected.Len() > p.protectedMax {
+		p.move(p.protected.Back().Value.(*tinyLFUEntry[K]), tinyLFUProbation)
+	}
+}
+
+// list returns the list holding the given region.
+func (p *tinyLFUPolicy[K]) list(region int) *list.List {
+	switch region {
+	case tinyLFUWindow:
+		return p.window
+	case tinyLFUProbation:
+		return p.probation
+	default:
+		return p.protected
+	}
+}
+
+// move puts entry at the front of the given region.
+func (p *tinyLFUPolicy[K]) move(entry *tinyLFUEntry[K], region int) {
+	p.list(entry.region).Remove(entry.node)
+	entry.region = region
+	entry.node = p.list(region).PushFront(entry)
+}
+
+func (p *tinyLFUPolicy[K]) OnInsert(key K) {
+	p.sketch.increment(key)
+
+	entry := &tinyLFUEntry[K]{key: key, region: tinyLFUWindow}
+	entry.node = p.window.PushFront(entry)
+	p.entries[key] = entry
+
+	// The window's least recently used key moves on to probation
+	if p.window.Len() > p.windowMax {
+		candidate := p.window.Back().Value.(*tinyLFUEntry[K])
+		p.move(candidate, tinyLFUProbation)
+		p.candidate = candidate
+	}
+}
+
+func (p *tinyLFUPolicy[K]) OnAccess(key K) {
+	p.sketch.increment(key)
+
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	if entry == p.candidate {
+		p.candidate = nil // A hit admits the candidate
+	}
+
+	switch entry.region {
+	case tinyLFUWindow:
+		p.window.MoveToFront(entry.node)
+	case tinyLFUProbation:
+		p.move(entry, tinyLFUProtected)
+		if p.protected.Len() > p.protectedMax {
+			p.move(p.protected.Back().Value.(*tinyLFUEntry[K]), tinyLFUProbation)
+		}
+	case tinyLFUProtected:
+		p.protected.MoveToFront(entry.node)
+	}
+}
+
+func (p *tinyLFUPolicy[K]) OnRemove(key K) {
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	if entry == p.candidate {
+		p.candidate = nil
+	}
+	p.list(entry.region).Remove(entry.node)
+	delete(p.entries, key)
+}
+
+// Victim runs the TinyLFU admission: the pending candidate competes with the
+// main space's least recently used key, and the less frequent one is evicted.
+func (p *tinyLFUPolicy[K]) Victim() (K, bool) {
+	candidate := p.candidate
+	p.candidate = nil
+
+	var victim *tinyLFUEntry[K]
+	if back := p.probation.Back(); back != nil && back.Value.(*tinyLFUEntry[K]) != candidate {
+		victim = back.Value.(*tinyLFUEntry[K])
+	} else if back := p.protected.Back(); back != nil {
+		victim = back.Value.(*tinyLFUEntry[K])
+	}
+
+	switch {
+	case candidate != nil && victim != nil:
+		if p.sketch.estimate(candidate.key) > p.sketch.estimate(victim.key) {
+			return victim.key, true
+		}
+		return candidate.key, true
+	case candidate != nil:
+		return candidate.key, true
+	case victim != nil:
+		return victim.key, true
+	case p.window.Len() > 0:
+		return p.window.Back().Value.(*tinyLFUEntry[K]).key, true
+	}
+
+	var zero K
+	return zero, false
+}
@@ -0,0 +1,110 @@
+package swiftcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCacheTinyLFUAdmission(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   3,
+		EvictionPolicy: "TinyLFU",
+	})
+
+	tc.Set("a", 1, NoExpiration)
+	for i := 0; i < 3; i++ {
+		tc.Get("a")
+	}
+	tc.Set("b", 2, NoExpiration)
+	tc.Set("c", 3, NoExpiration)
+
+	// c leaves the window, but is used less often than the main space's victim
+	tc.Set("d", 4, NoExpiration)
+	if _, found := tc.Get("c"); found {
+		t.Error("TinyLFU admission failed: c should have been rejected")
+	}
+
+	// A key that is used often while in the window displaces the victim
+	for i := 0; i < 5; i++ {
+		tc.Get("d")
+	}
+	tc.Set("e", 5, NoExpiration)
+	if _, found := tc.Get("d"); !found {
+		t.Error("TinyLFU admission failed: d should have been admitted")
+	}
+	if n := tc.ItemCount(); n != 3 {
+		t.Errorf("Item count is not 3: %d", n)
+	}
+}
+
+func TestCacheTinyLFUSurvivesScan(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{
+		SegmentCount:   1,
+		MaxCacheSize:   100,
+		EvictionPolicy: "TinyLFU",
+	})
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		tc.Set(key, i, NoExpiration)
+		for j := 0; j < 10; j++ {
+			tc.Get(key)
+		}
+	}
+
+	// A scan of keys that are only ever touched once
+	for i := 0; i < 1000; i++ {
+		tc.Set(fmt.Sprintf("scan%d", i), i, NoExpiration)
+	}
+
+	hits := 0
+	for i := 0; i < 50; i++ {
+		if _, found := tc.Get(fmt.Sprintf("hot%d", i)); found {
+			hits++
+		}
+	}
+	if hits < 45 {
+		t.Errorf("only %d of 50 hot keys survived the scan", hits)
+	}
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch[string](64)
+	for i := 0; i < 5; i++ {
+		s.increment("hot")
+	}
+	s.increment("cold")
+
+	if n := s.estimate("hot"); n < 5 {
+		t.Errorf("estimate for hot is %d, expected at least 5", n)
+	}
+	if n := s.estimate("cold"); n < 1 || n >= s.estimate("hot") {
+		t.Errorf("estimate for cold is %d", n)
+	}
+
+	s.reset()
+	if n := s.estimate("hot"); n > 3 {
+		t.Errorf("estimate for hot after reset is %d, expected it to be halved", n)
+	}
+}
+
+func TestTinyLFUPolicyResize(t *testing.T) {
+	p := newTinyLFUPolicy[string](100)
+	for i := 0; i < 5; i++ {
+		p.sketch.increment("hot")
+	}
+
+	p.Resize(1000)
+	if n := p.sketch.estimate("hot"); n < 5 {
+		t.Errorf("estimate after growing is %d, expected at least 5", n)
+	}
+	if len(p.sketch.rows[0]) != sketchWidth(1000) {
+		t.Errorf("sketch width is %d, expected %d", len(p.sketch.rows[0]), sketchWidth(1000))
+	}
+
+	p.Resize(10)
+	if n := p.sketch.estimate("hot"); n < 5 {
+		t.Errorf("estimate after shrinking is %d, expected at least 5", n)
+	}
+}
@@ -0,0 +1,102 @@
+package swiftcache
+
+import "time"
+
+// Touch makes the item for key expire ttl from now, without changing its value
+// or its position in the eviction order. The ttl follows the rules of Set,
+// except that the item is no longer served as stale past it, so that TTL then
+// reports ttl. KeepTTL leaves the expiration as it is. A sliding item slides
+// by ttl from now on, and its maximum lifetime still applies. It reports
+// whether the key was found.
+func (c *Cache[K, V]) Touch(key K, ttl time.Duration) bool {
+	if ttl == DefaultExpiration {
+		ttl = c.defaultExpiration
+	}
+	segment := c.getSegment(key)
+	if segment == nil {
+		return false
+	}
+	return segment.modify(key, func(item *Item[V], now int64) bool {
+		if ttl == KeepTTL {
+			return true
+		}
+		if item.idle > 0 {
+			item.idle = max(int64(ttl), 0)
+		}
+		item.stale = 0
+		segment.extend(key, item, ttl, now)
+		return true
+	})
+}
+
+// ExpireAt makes the item for key expire at t, without changing its value or
+// its position in the eviction order. The item is not served as stale past t.
+// A sliding item stops sliding, and its maximum lifetime still applies. If t
+// is not in the future, the item is removed right away. It reports whether
+// the key was found.
+func (c *Cache[K, V]) ExpireAt(key K, t time.Time) bool {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return false
+	}
+	return segment.modify(key, func(item *Item[V], now int64) bool {
+		ttl := time.Duration(t.UnixNano() - now)
+		if ttl <= 0 {
+			return false
+		}
+		item.stale, item.idle = 0, 0
+		segment.extend(key, item, ttl, now)
+		return true
+	})
+}
+
+// Persist removes the expiration of the item for key, including any stale
+// window, sliding and maximum lifetime, so it never expires. It does not
+// change its value or its position in the eviction order, and reports
+// whether the key was found.
+func (c *Cache[K, V]) Persist(key K) bool {
+	segment := c.getSegment(key)
+	if segment == nil {
+		return false
+	}
+	return segment.modify(key, func(item *Item[V], now int64) bool {
+		item.stale, item.idle, item.deadline = 0, 0, 0
+		segment.setExpiration(key, item, 0)
+		return true
+	})
+}
+
+// TTL returns the time left until the item for key expires, or NoExpiration
+// if it never does, and whether the key was found.
+func (c *Cache[K, V]) TTL(key K) (time.Duration, bool) {
+	_, expiration, found := c.GetWithExpiration(key)
+	switch {
+	case !found:
+		return 0, false
+	case expiration.IsZero():
+		return NoExpiration, true
+	}
+	// The item was live a moment ago, so report at least some time left
+	return max(time.Until(expiration), 1), true
+}
+
+// modify calls f with the live item for key while holding the write lock, and
+// reports whether there was one. An expired item is removed instead of being
+// passed to f, and so is the item if f returns false.
+func (s *Segment[K, V]) modify(key K, f func(item *Item[V], now int64) bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item, exists := s.items[key]
+	if !exists {
+		return false
+	}
+	if item.Expired() {
+		s.removeKey(key)
+		return false
+	}
+	if !f(item, time.Now().UnixNano()) {
+		s.removeKey(key)
+	}
+	return true
+}
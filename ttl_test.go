@@ -0,0 +1,186 @@
+package swiftcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTouch(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		EvictionPolicy:    "LRU",
+		MaxCacheSize:      2,
+		SegmentCount:      1,
+		DefaultExpiration: time.Hour,
+	})
+
+	tc.Set("a", 1, 20*time.Millisecond)
+	tc.Set("b", 2, NoExpiration)
+	if !tc.Touch("a", time.Minute) {
+		t.Fatal("Touch did not find the key")
+	}
+	<-time.After(30 * time.Millisecond)
+	if v, found := tc.Get("b"); !found || v != 2 {
+		t.Fatal("b is missing")
+	}
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Fatal("a expired after it was touched")
+	}
+	if ttl, _ := tc.TTL("a"); ttl > time.Minute || ttl < 59*time.Second {
+		t.Errorf("got a ttl of %v, expected a minute", ttl)
+	}
+
+	tc.Touch("b", DefaultExpiration)
+	if ttl, _ := tc.TTL("b"); ttl < 59*time.Minute {
+		t.Errorf("got a ttl of %v, expected the default of an hour", ttl)
+	}
+	if !tc.Touch("a", KeepTTL) {
+		t.Error("Touch with KeepTTL did not find the key")
+	}
+	if ttl, _ := tc.TTL("a"); ttl > time.Minute || ttl < 59*time.Second {
+		t.Errorf("got a ttl of %v after Touch with KeepTTL, expected a minute", ttl)
+	}
+	tc.Touch("b", NoExpiration)
+	if ttl, _ := tc.TTL("b"); ttl != NoExpiration {
+		t.Errorf("got a ttl of %v, expected NoExpiration", ttl)
+	}
+
+	if tc.Touch("missing", time.Minute) {
+		t.Error("Touch found a missing key")
+	}
+}
+
+func TestTouchKeepsEvictionOrder(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{
+		EvictionPolicy: "LRU",
+		MaxCacheSize:   2,
+		SegmentCount:   1,
+	})
+
+	tc.Set("a", 1, time.Minute)
+	tc.Set("b", 2, time.Minute)
+	tc.Touch("a", time.Hour)
+	tc.Set("c", 3, time.Minute)
+
+	if _, found := tc.Get("a"); found {
+		t.Error("Touch moved the key up in the LRU order")
+	}
+}
+
+func TestTouchSliding(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.SetSliding("a", 1, 20*time.Millisecond, time.Hour)
+	tc.Touch("a", time.Minute)
+	<-time.After(30 * time.Millisecond)
+	tc.Get("a")
+	if ttl, found := tc.TTL("a"); !found || ttl < 59*time.Second {
+		t.Errorf("got a ttl of %v, expected the item to slide by the touched ttl", ttl)
+	}
+
+	tc.Touch("a", 2*time.Hour)
+	if ttl, _ := tc.TTL("a"); ttl > time.Hour {
+		t.Errorf("got a ttl of %v, beyond the maximum lifetime", ttl)
+	}
+}
+
+func TestExpireAt(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	var evicted []string
+	tc.OnEvicted(func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	at := time.Now().Add(time.Minute)
+	tc.Set("a", 1, NoExpiration)
+	if !tc.ExpireAt("a", at) {
+		t.Fatal("ExpireAt did not find the key")
+	}
+	if _, expiration, _ := tc.GetWithExpiration("a"); !expiration.Equal(time.Unix(0, at.UnixNano())) {
+		t.Errorf("got an expiration of %v, expected %v", expiration, at)
+	}
+
+	tc.Set("b", 2, NoExpiration)
+	if !tc.ExpireAt("b", time.Now().Add(-time.Second)) {
+		t.Error("ExpireAt did not find the key")
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("an item set to expire in the past was not removed")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("OnEvicted was called for %v, expected b", evicted)
+	}
+
+	if tc.ExpireAt("missing", at) {
+		t.Error("ExpireAt found a missing key")
+	}
+}
+
+func TestExpireAtStale(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{StaleTTL: time.Hour})
+
+	at := time.Now().Add(time.Minute)
+	tc.Set("a", 1, time.Minute)
+	tc.ExpireAt("a", at)
+	if _, expiration, _ := tc.GetWithExpiration("a"); !expiration.Equal(time.Unix(0, at.UnixNano())) {
+		t.Errorf("got an expiration of %v, expected %v without the stale window", expiration, at)
+	}
+
+	tc.Set("b", 2, time.Minute)
+	tc.ExpireAt("b", time.Now().Add(20*time.Millisecond))
+	<-time.After(30 * time.Millisecond)
+	if _, found := tc.Get("b"); found {
+		t.Error("an item was served as stale past the time set by ExpireAt")
+	}
+
+	tc.Set("c", 3, time.Minute)
+	tc.Touch("c", 10*time.Second)
+	if ttl, _ := tc.TTL("c"); ttl > 10*time.Second || ttl < 9*time.Second {
+		t.Errorf("got a ttl of %v after Touch, expected 10s without the stale window", ttl)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	tc, _ := New[string, int](Config[string, int]{StaleTTL: time.Minute})
+
+	tc.SetSliding("a", 1, 20*time.Millisecond, 20*time.Millisecond)
+	if !tc.Persist("a") {
+		t.Fatal("Persist did not find the key")
+	}
+	<-time.After(30 * time.Millisecond)
+	if _, found := tc.Get("a"); !found {
+		t.Error("a persisted item expired")
+	}
+	if ttl, _ := tc.TTL("a"); ttl != NoExpiration {
+		t.Errorf("got a ttl of %v, expected NoExpiration", ttl)
+	}
+	if item, _ := tc.Item("a"); item.Expiration != 0 || item.heapIndex != -1 {
+		t.Error("a persisted item is still in the expiration index")
+	}
+
+	if tc.Persist("missing") {
+		t.Error("Persist found a missing key")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tc, _ := New[string, int]()
+
+	tc.Set("a", 1, time.Minute)
+	tc.Set("b", 2, NoExpiration)
+	tc.Set("c", 3, time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+
+	if ttl, found := tc.TTL("a"); !found || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("got %v, %v; expected about a minute", ttl, found)
+	}
+	if ttl, found := tc.TTL("b"); !found || ttl != NoExpiration {
+		t.Errorf("got %v, %v; expected NoExpiration", ttl, found)
+	}
+	if _, found := tc.TTL("c"); found {
+		t.Error("TTL found an expired item")
+	}
+	if _, found := tc.TTL("missing"); found {
+		t.Error("TTL found a missing key")
+	}
+}
@@ -0,0 +1,24 @@
+package swiftcache
+
+import "errors"
+
+// ErrItemTooLarge is returned when a single item weighs more than a segment may hold.
+var ErrItemTooLarge = errors.New("item weight exceeds the cache capacity")
+
+// Sizer can be implemented by values to report their own weight, usually
+// their approximate size in bytes, when the cache has no Weigher configured.
+type Sizer interface {
+	Size() int64
+}
+
+// weigh computes the weight of an item: the configured Weigher if any,
+// otherwise the value's own Size if it implements Sizer, otherwise 1.
+func (c *Cache[K, V]) weigh(key K, value V) int64 {
+	if c.weigher != nil {
+		return c.weigher(key, value)
+	}
+	if sizer, ok := any(value).(Sizer); ok {
+		return sizer.Size()
+	}
+	return 1
+}
@@ -0,0 +1,89 @@
+package swiftcache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCacheWeigher(t *testing.T) {
+	tc, _ := New[string, string](Config[string, string]{
+		SegmentCount: 1,
+		MaxBytes:     100,
+		Weigher: func(key string, value string) int64 {
+			return int64(len(value))
+		},
+	})
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := tc.Set(key, strings.Repeat("x", 30), NoExpiration); err != nil {
+			t.Fatal("Error setting:", err)
+		}
+	}
+	if w := tc.segments[0].weight; w != 90 {
+		t.Errorf("segment weight is %d, expected 90", w)
+	}
+
+	// 120 bytes do not fit, so the least recently used item goes
+	tc.Set("d", strings.Repeat("x", 30), NoExpiration)
+	if _, found := tc.Get("a"); found {
+		t.Error("a should have been evicted to make room for d")
+	}
+	if w := tc.segments[0].weight; w != 90 {
+		t.Errorf("segment weight is %d, expected 90", w)
+	}
+
+	// Growing an existing item evicts others until it fits
+	tc.Set("d", strings.Repeat("x", 80), NoExpiration)
+	if n := tc.ItemCount(); n != 1 {
+		t.Errorf("Item count is not 1 after growing d: %d", n)
+	}
+	if w := tc.segments[0].weight; w != 80 {
+		t.Errorf("segment weight is %d, expected 80", w)
+	}
+
+	tc.Delete("d")
+	if w := tc.segments[0].weight; w != 0 {
+		t.Errorf("segment weight is %d after deleting everything", w)
+	}
+}
+
+type sizedBlob []byte
+
+func (b sizedBlob) Size() int64 {
+	return int64(len(b))
+}
+
+func TestCacheSizer(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 1, MaxBytes: 10})
+	tc.Set("a", sizedBlob("12345"), NoExpiration)
+	tc.Set("b", sizedBlob("12345"), NoExpiration)
+	tc.Set("c", "not sized", NoExpiration) // Weighs 1
+
+	if _, found := tc.Get("a"); found {
+		t.Error("a should have been evicted to make room for c")
+	}
+	if w := tc.segments[0].weight; w != 6 {
+		t.Errorf("segment weight is %d, expected 6", w)
+	}
+}
+
+func TestCacheItemTooLarge(t *testing.T) {
+	tc, _ := NewCache(CacheConfig{SegmentCount: 1, MaxBytes: 10})
+	tc.Set("a", sizedBlob("small"), NoExpiration)
+
+	err := tc.Set("a", sizedBlob("far too large"), NoExpiration)
+	if !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("Set returned %v, expected ErrItemTooLarge", err)
+	}
+	if x, _ := tc.Get("a"); string(x.(sizedBlob)) != "small" {
+		t.Error("a rejected update replaced the existing value:", x)
+	}
+
+	if err := tc.Set("b", sizedBlob("far too large"), NoExpiration); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("Set returned %v, expected ErrItemTooLarge", err)
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("b was stored even though it is too large")
+	}
+}